@@ -0,0 +1,96 @@
+package stzr
+
+// Removal describes the content sanitization removed from a string value.
+type Removal struct {
+	// Path is the location of the value, e.g. "Comments[0].Author".
+	Path string
+	// Policy is the name of the applied policy.
+	Policy string
+	// Removed lists the fragments of the value missing from the sanitized
+	// value, in order.
+	Removed []string
+}
+
+// SanitizeStructAudit applies sanitization like SanitizeStruct and returns
+// what was removed from each modified value, in traversal order. On error,
+// the removals reflect the fields sanitized before the walk stopped.
+//
+// Removed fragments are heuristic: they are computed from the values before
+// and after sanitization, not reported by the policies. Parts rewritten
+// rather than dropped, e.g. by escaping, show up as removed fragments, and
+// values only gaining content have none. Large values whose changed part
+// is too costly to diff report that part as a single fragment.
+func (s *Sanitizer) SanitizeStructAudit(v any) ([]Removal, error) {
+	w := &walker{Sanitizer: s, audit: true}
+	err := s.walk(v, w)
+
+	removals := make([]Removal, len(w.changes))
+	for i, c := range w.changes {
+		removals[i] = Removal{Path: c.Path, Policy: c.Policy, Removed: removed(c.Before, c.After)}
+	}
+	return removals, err
+}
+
+// maxDiffCells bounds the size of the table used to diff a value.
+const maxDiffCells = 1 << 20
+
+// removed returns the runs of before missing from after, based on the
+// longest common subsequence of their runes.
+func removed(before, after string) []string {
+	b, a := []rune(before), []rune(after)
+	for len(b) > 0 && len(a) > 0 && b[0] == a[0] {
+		b, a = b[1:], a[1:]
+	}
+	for len(b) > 0 && len(a) > 0 && b[len(b)-1] == a[len(a)-1] {
+		b, a = b[:len(b)-1], a[:len(a)-1]
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	if (len(b)+1)*(len(a)+1) > maxDiffCells {
+		return []string{string(b)}
+	}
+
+	// lcs[i*cols+j] is the length of the longest common subsequence of
+	// b[i:] and a[j:].
+	cols := len(a) + 1
+	lcs := make([]int, (len(b)+1)*cols)
+	for i := len(b) - 1; i >= 0; i-- {
+		for j := len(a) - 1; j >= 0; j-- {
+			if b[i] == a[j] {
+				lcs[i*cols+j] = lcs[(i+1)*cols+j+1] + 1
+			} else {
+				lcs[i*cols+j] = max(lcs[(i+1)*cols+j], lcs[i*cols+j+1])
+			}
+		}
+	}
+
+	var fragments []string
+	start := -1
+	for i, j := 0, 0; i < len(b); {
+		switch {
+		case start >= 0 && lcs[(i+1)*cols+j] == lcs[i*cols+j]:
+			// Extend the current fragment as long as it costs no common
+			// runes, so fragments are not split by stray matches.
+			i++
+		case j < len(a) && b[i] == a[j]:
+			if start >= 0 {
+				fragments = append(fragments, string(b[start:i]))
+				start = -1
+			}
+			i++
+			j++
+		case j < len(a) && lcs[i*cols+j+1] >= lcs[(i+1)*cols+j]:
+			j++
+		default:
+			if start < 0 {
+				start = i
+			}
+			i++
+		}
+	}
+	if start >= 0 {
+		fragments = append(fragments, string(b[start:]))
+	}
+	return fragments
+}
@@ -0,0 +1,77 @@
+package stzr_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kraciasty/stzr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizer_SanitizeStructAudit(t *testing.T) {
+	s := stzr.NewWithDefaults(stzr.WithPolicy("trim", stzr.PolicyFunc(strings.TrimSpace)))
+
+	type comment struct {
+		Author string `sanitize:"strict"`
+		Text   string `sanitize:"ugc"`
+	}
+	input := struct {
+		Title    string `sanitize:"strict"`
+		Clean    string `sanitize:"strict"`
+		Snippet  string `sanitize:"escape"`
+		Name     string `sanitize:"strict,trim"`
+		Comments []comment
+	}{
+		Title:    "<b>Rick</b><script>alert(1)</script>",
+		Clean:    "Morty",
+		Snippet:  "a & b",
+		Name:     "  <i>Summer</i> ",
+		Comments: []comment{{Author: "Jerry", Text: `Hi <a href="javascript:x()">there</a> <b>Beth</b>`}},
+	}
+
+	removals, err := s.SanitizeStructAudit(&input)
+	require.NoError(t, err)
+	assert.Equal(t, []stzr.Removal{
+		{Path: "Title", Policy: "strict", Removed: []string{"<b>", "</b><script>alert(1)</script>"}},
+		{Path: "Snippet", Policy: "escape"},
+		{Path: "Name", Policy: "strict,trim", Removed: []string{"  <i>", "</i> "}},
+		{Path: "Comments[0].Text", Policy: "ugc", Removed: []string{`<a href="javascript:x()">`, "</a>"}},
+	}, removals)
+	assert.Equal(t, "Rick", input.Title)
+	assert.Equal(t, "a &amp; b", input.Snippet)
+
+	t.Run("unchanged", func(t *testing.T) {
+		clean := comment{Author: "Rick", Text: "<b>Wubba lubba</b>"}
+		removals, err := s.SanitizeStructAudit(&clean)
+		require.NoError(t, err)
+		assert.Empty(t, removals)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		broken := struct {
+			Title string `sanitize:"strict"`
+			Body  string `sanitize:"unknown"`
+		}{Title: "<b>Rick</b>", Body: "x"}
+
+		removals, err := s.SanitizeStructAudit(&broken)
+		assert.ErrorIs(t, err, stzr.ErrPolicyNotFound)
+		assert.Equal(t, []stzr.Removal{{Path: "Title", Policy: "strict", Removed: []string{"<b>", "</b>"}}}, removals)
+	})
+
+	t.Run("large value", func(t *testing.T) {
+		text := strings.Repeat("wubba lubba ", 1000)
+		large := struct {
+			Short string `sanitize:"strict"`
+			Long  string `sanitize:"strict"`
+		}{Short: text + "<script>x</script>" + text, Long: "<b>" + text + "</b>"}
+		long := large.Long
+
+		removals, err := s.SanitizeStructAudit(&large)
+		require.NoError(t, err)
+		assert.Equal(t, []stzr.Removal{
+			{Path: "Short", Policy: "strict", Removed: []string{"<script>x</script>"}},
+			{Path: "Long", Policy: "strict", Removed: []string{long}},
+		}, removals, "changed parts too large to diff are reported whole")
+	})
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kraciasty/stzr"
+)
+
+const generatedMarker = "Code generated by stzrgen"
+
+// errUnsupported is returned for fields the generator cannot handle.
+var errUnsupported = errors.New("unsupported field")
+
+// builtins lists predeclared types that never hold strings.
+var builtins = map[string]bool{
+	"bool": true, "byte": true, "rune": true, "uintptr": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true,
+}
+
+type generator struct {
+	tagKey  string
+	specs   map[string]*ast.TypeSpec
+	queue   []string
+	queued  map[string]bool
+	usesFmt bool
+}
+
+// generate returns the formatted source of Sanitize methods for the named
+// types of the package in dir.
+func generate(dir string, typeNames []string, tagKey string) ([]byte, error) {
+	pkg, specs, err := parsePackage(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		tagKey: tagKey,
+		specs:  specs,
+		queued: make(map[string]bool),
+	}
+
+	for _, name := range typeNames {
+		spec, ok := specs[name]
+		if !ok {
+			return nil, fmt.Errorf("type %q not found in %s", name, dir)
+		}
+		if _, ok := spec.Type.(*ast.StructType); !ok {
+			return nil, fmt.Errorf("type %q is not a struct", name)
+		}
+		g.enqueue(name)
+	}
+
+	var body bytes.Buffer
+	for len(g.queue) > 0 {
+		name := g.queue[0]
+		g.queue = g.queue[1:]
+		if err := g.method(&body, g.specs[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// %s; DO NOT EDIT.\n\n", generatedMarker)
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	buf.WriteString("import (\n")
+	if g.usesFmt {
+		buf.WriteString("\t\"fmt\"\n\n")
+	}
+	buf.WriteString("\t\"github.com/kraciasty/stzr\"\n)\n")
+	buf.Write(body.Bytes())
+
+	return format.Source(buf.Bytes())
+}
+
+// parsePackage parses the non-test, non-generated Go files in dir and
+// returns the package name and its type declarations.
+func parsePackage(dir string) (string, map[string]*ast.TypeSpec, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pkg string
+	fset := token.NewFileSet()
+	specs := make(map[string]*ast.TypeSpec)
+	for _, name := range names {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return "", nil, err
+		}
+		if isGenerated(f) {
+			continue
+		}
+
+		pkg = f.Name.Name
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				specs[ts.Name.Name] = ts
+			}
+		}
+	}
+
+	if pkg == "" {
+		return "", nil, fmt.Errorf("no Go files in %s", dir)
+	}
+	return pkg, specs, nil
+}
+
+func isGenerated(f *ast.File) bool {
+	for _, c := range f.Comments {
+		if c.Pos() > f.Package {
+			break
+		}
+		if strings.Contains(c.Text(), generatedMarker) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) enqueue(name string) {
+	if g.queued[name] {
+		return
+	}
+	g.queued[name] = true
+	g.queue = append(g.queue, name)
+}
+
+// method writes the Sanitize method of a struct type.
+func (g *generator) method(w *bytes.Buffer, spec *ast.TypeSpec) error {
+	if spec.TypeParams != nil {
+		return fmt.Errorf("type %s: generic types: %w", spec.Name.Name, errUnsupported)
+	}
+
+	var body bytes.Buffer
+	st := spec.Type.(*ast.StructType)
+	for _, field := range st.Fields.List {
+		tag := ""
+		if field.Tag != nil {
+			raw, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag = reflect.StructTag(raw).Get(g.tagKey)
+		}
+
+		if tag == stzr.SkipMarker {
+			continue
+		}
+		if strings.ContainsAny(tag, ",;=") {
+			return fmt.Errorf("type %s: tag %q: inline options: %w", spec.Name.Name, tag, errUnsupported)
+		}
+
+		for _, name := range fieldNames(field) {
+			// Exported fields of embedded unexported structs are settable,
+			// so the walker sanitizes them as well.
+			if !ast.IsExported(name) && !g.embedsStruct(field) {
+				continue
+			}
+			if err := g.emit(&body, "x."+name, field.Type, tag, name, "", 0); err != nil {
+				return fmt.Errorf("type %s: field %s: %w", spec.Name.Name, name, err)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\n// Sanitize sanitizes the tagged fields of x using s.\n")
+	fmt.Fprintf(w, "func (x *%s) Sanitize(s *stzr.Sanitizer) error {\n", spec.Name.Name)
+	w.Write(body.Bytes())
+	w.WriteString("return nil\n}\n")
+	return nil
+}
+
+// embedsStruct reports whether field embeds a struct type, or a pointer to
+// one, declared in the package.
+func (g *generator) embedsStruct(field *ast.Field) bool {
+	if len(field.Names) > 0 {
+		return false
+	}
+	typ := field.Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	id, ok := typ.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	spec, ok := g.specs[id.Name]
+	if !ok {
+		return false
+	}
+	_, ok = spec.Type.(*ast.StructType)
+	return ok
+}
+
+// fieldNames returns the names of a field, embedded fields are named after
+// their type.
+func fieldNames(field *ast.Field) []string {
+	if len(field.Names) > 0 {
+		names := make([]string, len(field.Names))
+		for i, n := range field.Names {
+			names[i] = n.Name
+		}
+		return names
+	}
+
+	typ := field.Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	switch t := typ.(type) {
+	case *ast.Ident:
+		return []string{t.Name}
+	case *ast.SelectorExpr:
+		return []string{t.Sel.Name}
+	}
+	return nil
+}
+
+// emit writes code sanitizing the value of expr, whose type is typ. The
+// policy is inherited from the field tag, named is the name of the named
+// string type being resolved, if any.
+func (g *generator) emit(w *bytes.Buffer, expr string, typ ast.Expr, policy, path, named string, depth int) error {
+	switch t := typ.(type) {
+	case *ast.Ident:
+		return g.emitIdent(w, expr, t, policy, path, named, depth)
+	case *ast.ParenExpr:
+		return g.emit(w, expr, t.X, policy, path, named, depth)
+	case *ast.StarExpr:
+		var elem bytes.Buffer
+		if err := g.emit(&elem, "(*"+expr+")", t.X, policy, path, "", depth); err != nil {
+			return err
+		}
+		if elem.Len() > 0 {
+			fmt.Fprintf(w, "if %s != nil {\n%s}\n", expr, elem.Bytes())
+		}
+	case *ast.ArrayType:
+		i := fmt.Sprintf("i%d", depth)
+		var elem bytes.Buffer
+		if err := g.emit(&elem, expr+"["+i+"]", t.Elt, policy, path, "", depth+1); err != nil {
+			return err
+		}
+		if elem.Len() > 0 {
+			fmt.Fprintf(w, "for %s := range %s {\n%s}\n", i, expr, elem.Bytes())
+		}
+	case *ast.MapType:
+		k, v := fmt.Sprintf("k%d", depth), fmt.Sprintf("v%d", depth)
+		var elem bytes.Buffer
+		if err := g.emit(&elem, v, t.Value, policy, path, "", depth+1); err != nil {
+			return err
+		}
+		if elem.Len() > 0 {
+			fmt.Fprintf(w, "for %s, %s := range %s {\n%s%s[%s] = %s\n}\n", k, v, expr, elem.Bytes(), expr, k, v)
+		}
+	case *ast.InterfaceType, *ast.SelectorExpr:
+		if policy != "" {
+			return fmt.Errorf("tagged %T: %w", t, errUnsupported)
+		}
+		g.emitFallback(w, expr, path)
+	case *ast.StructType:
+		return fmt.Errorf("anonymous struct: %w", errUnsupported)
+	case *ast.ChanType, *ast.FuncType:
+		// Channels and functions never hold sanitizable strings.
+	default:
+		return fmt.Errorf("type %T: %w", t, errUnsupported)
+	}
+	return nil
+}
+
+func (g *generator) emitIdent(w *bytes.Buffer, expr string, id *ast.Ident, policy, path, named string, depth int) error {
+	switch {
+	case id.Name == "string":
+		if policy != "" {
+			g.emitString(w, expr, policy, path, named)
+		}
+		return nil
+	case id.Name == "any" || id.Name == "error":
+		return g.emit(w, expr, &ast.InterfaceType{}, policy, path, named, depth)
+	case builtins[id.Name]:
+		return nil
+	}
+
+	spec, ok := g.specs[id.Name]
+	if !ok {
+		return fmt.Errorf("type %s: %w", id.Name, errUnsupported)
+	}
+
+	if _, ok := spec.Type.(*ast.StructType); ok {
+		if spec.TypeParams != nil {
+			return fmt.Errorf("generic type %s: %w", id.Name, errUnsupported)
+		}
+		g.enqueue(id.Name)
+		g.usesFmt = true
+		fmt.Fprintf(w, "if err := %s.Sanitize(s); err != nil {\nreturn fmt.Errorf(\"%s: %%w\", err)\n}\n", expr, path)
+		return nil
+	}
+
+	if named == "" {
+		named = id.Name
+	}
+	return g.emit(w, expr, spec.Type, policy, path, named, depth)
+}
+
+func (g *generator) emitString(w *bytes.Buffer, expr, policy, path, named string) {
+	g.usesFmt = true
+	in, out := expr, "clean"
+	if named != "" {
+		in, out = "string("+expr+")", named+"(clean)"
+	}
+
+	fmt.Fprintf(w, "if clean, err := s.SanitizeString(%q, %s); err != nil {\n", policy, in)
+	fmt.Fprintf(w, "return fmt.Errorf(\"%s: %%w\", err)\n", path)
+	fmt.Fprintf(w, "} else {\n%s = %s\n}\n", expr, out)
+}
+
+func (g *generator) emitFallback(w *bytes.Buffer, expr, path string) {
+	g.usesFmt = true
+	fmt.Fprintf(w, "if err := s.SanitizeStruct(&%s); err != nil {\n", expr)
+	fmt.Fprintf(w, "return fmt.Errorf(\"%s: %%w\", err)\n}\n", path)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     string
+		types   []string
+		golden  string
+		wantErr bool
+		errIs   error
+	}{
+		{
+			name:   "nested types",
+			dir:    "testdata/episode",
+			types:  []string{"Episode"},
+			golden: "testdata/episode/episode_sanitize.go.golden",
+		},
+		{
+			name:    "inline options",
+			dir:     "testdata/options",
+			types:   []string{"Post"},
+			wantErr: true,
+			errIs:   errUnsupported,
+		},
+		{
+			name:    "unknown type",
+			dir:     "testdata/episode",
+			types:   []string{"Missing"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := generate(tt.dir, tt.types, "sanitize")
+			if tt.wantErr {
+				require.Error(t, err)
+				if tt.errIs != nil {
+					assert.ErrorIs(t, err, tt.errIs)
+				}
+				return
+			}
+			require.NoError(t, err)
+
+			if *update {
+				require.NoError(t, os.WriteFile(tt.golden, got, 0o644))
+			}
+
+			want, err := os.ReadFile(filepath.Clean(tt.golden))
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
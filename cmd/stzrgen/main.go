@@ -0,0 +1,71 @@
+// Command stzrgen generates reflection-free Sanitize methods for struct types
+// with sanitization tags.
+//
+// Usage:
+//
+//	stzrgen -type Character,Episode [-tag sanitize] [-output file] [dir]
+//
+// For every requested type, and every struct type of the same package
+// reachable from its fields, stzrgen emits a method
+//
+//	func (x *T) Sanitize(s *stzr.Sanitizer) error
+//
+// that applies the same tag semantics as [stzr.Sanitizer.SanitizeStruct]:
+// tagged strings are sanitized with [stzr.Sanitizer.SanitizeString], the
+// tag of a field applies to strings inside its pointers, slices, arrays and
+// maps, "-" skips a field and nested structs, including embedded unexported
+// ones, are sanitized by their own generated methods. Fields whose types are declared in other packages or
+// are interfaces fall back to the reflection based SanitizeStruct.
+//
+// Tags with inline options and sanitizer options such as a default policy
+// are not supported by generated code.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	var (
+		typeNames = flag.String("type", "", "comma-separated list of type names; required")
+		tagKey    = flag.String("tag", "sanitize", "struct tag key holding sanitization policies")
+		output    = flag.String("output", "", "output file name; default <dir>/<type>_sanitize.go")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: stzrgen -type T [-tag key] [-output file] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	types := strings.Split(*typeNames, ",")
+	src, err := generate(dir, types, *tagKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "stzrgen: %v\n", err)
+		os.Exit(1)
+	}
+
+	out := *output
+	if out == "" {
+		out = filepath.Join(dir, strings.ToLower(types[0])+"_sanitize.go")
+	}
+
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "stzrgen: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,41 @@
+package episode
+
+import "time"
+
+type Title string
+
+type Tags []string
+
+type Episode struct {
+	ID          int
+	Title       Title  `sanitize:"strict"`
+	Description string `sanitize:"ugc"`
+	Plain       string
+	Skipped     Comment `sanitize:"-"`
+	Comments    []Comment
+	Pinned      *Comment
+	Notes       *string           `sanitize:"strict"`
+	Tags        Tags              `sanitize:"strict"`
+	Labels      map[string]string `sanitize:"strict"`
+	ByAuthor    map[string][]Comment
+	Extra       any
+	AiredAt     time.Time
+	unexported  string `sanitize:"strict"`
+	Meta
+	*credits
+}
+
+type Comment struct {
+	Author string `sanitize:"strict"`
+	Text   string `sanitize:"ugc"`
+	Score  float64
+}
+
+type Meta struct {
+	Source string `sanitize:"strict"`
+}
+
+type credits struct {
+	Writer string `sanitize:"strict"`
+	draft  string `sanitize:"strict"`
+}
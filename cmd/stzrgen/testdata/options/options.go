@@ -0,0 +1,5 @@
+package options
+
+type Post struct {
+	Body string `sanitize:"ugc;allow=abbr"`
+}
@@ -0,0 +1,156 @@
+package stzr
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// Config declares a Sanitizer as plain data, e.g. loaded from a JSON or YAML
+// file, to be built with Configure.
+type Config struct {
+	// TagKey is the tag key used for sanitization policies, "sanitize" when
+	// empty.
+	TagKey string `json:"tagKey,omitempty"`
+	// DefaultPolicy is the policy applied to untagged strings, none when
+	// empty. It must name one of Policies.
+	DefaultPolicy string `json:"defaultPolicy,omitempty"`
+	// UnknownPolicy selects how fields tagged with unregistered policies are
+	// handled, either "error", the default, or "skip".
+	UnknownPolicy string `json:"unknownPolicy,omitempty"`
+	// Policies are the policies registered with the Sanitizer.
+	Policies []PolicySpec `json:"policies,omitempty"`
+}
+
+// PolicySpec declares a bluemonday policy allowing the listed elements,
+// attributes and URL schemes on top of its base. It is compiled with Build.
+type PolicySpec struct {
+	// Name is the name the policy is registered under by Configure.
+	Name string `json:"name"`
+	// Base is the policy extended by the spec, either "strict", the default,
+	// or "ugc".
+	Base string `json:"base,omitempty"`
+	// Elements are the additionally allowed elements, e.g. "abbr".
+	Elements []string `json:"elements,omitempty"`
+	// Attributes are the additionally allowed attributes.
+	Attributes []AttributeSpec `json:"attributes,omitempty"`
+	// URLSchemes are the additionally allowed schemes of URLs in attributes
+	// such as href, e.g. "https" or "mailto".
+	URLSchemes []string `json:"urlSchemes,omitempty"`
+	// RequireNoopener opens fully qualified links in a new tab with
+	// rel="noopener", which keeps the linked page from accessing the opener.
+	RequireNoopener bool `json:"requireNoopener,omitempty"`
+}
+
+// AttributeSpec declares an attribute allowed on the listed elements, or on
+// any element when none are listed.
+type AttributeSpec struct {
+	Name     string   `json:"name"`
+	Elements []string `json:"elements,omitempty"`
+}
+
+// Configure creates a Sanitizer from cfg, registering its policies with
+// support for inline tag options. Invalid configurations yield an error
+// wrapping ErrInvalidConfig, or ErrInvalidPolicyName for empty or reserved
+// policy names. The result may be installed with SetDefault.
+func Configure(cfg Config) (*Sanitizer, error) {
+	opts := []Opt{WithDefaultPolicy(cfg.DefaultPolicy)}
+	if cfg.TagKey != "" {
+		opts = append(opts, WithTagKey(cfg.TagKey))
+	}
+
+	switch cfg.UnknownPolicy {
+	case "", "error":
+		opts = append(opts, WithUnknownPolicy(ErrorOnUnknown))
+	case "skip":
+		opts = append(opts, WithUnknownPolicy(SkipOnUnknown))
+	default:
+		return nil, fmt.Errorf("unknown policy mode %q: %w", cfg.UnknownPolicy, ErrInvalidConfig)
+	}
+
+	names := make(map[string]bool, len(cfg.Policies))
+	for _, spec := range cfg.Policies {
+		if spec.Name == "" || spec.Name == SkipMarker {
+			return nil, fmt.Errorf("policy %q: %w", spec.Name, ErrInvalidPolicyName)
+		}
+		if err := spec.validate(); err != nil {
+			return nil, err
+		}
+		if names[spec.Name] {
+			return nil, fmt.Errorf("policy %q: duplicate name: %w", spec.Name, ErrInvalidConfig)
+		}
+		names[spec.Name] = true
+		opts = append(opts, WithPolicyBuilder(spec.Name, spec.build))
+	}
+
+	if cfg.DefaultPolicy != "" && !names[cfg.DefaultPolicy] {
+		return nil, fmt.Errorf("default policy %q is not configured: %w", cfg.DefaultPolicy, ErrInvalidConfig)
+	}
+
+	return New(opts...), nil
+}
+
+// Build compiles the spec into a bluemonday policy. Invalid specs yield an
+// error wrapping ErrInvalidConfig. The name of the spec is not validated, as
+// it only matters to Configure.
+func (p PolicySpec) Build() (*bluemonday.Policy, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return p.build(), nil
+}
+
+// validate reports whether the spec, except for its name, is valid.
+func (p PolicySpec) validate() error {
+	switch p.Base {
+	case "", "strict", "ugc":
+	default:
+		return fmt.Errorf("policy %q: unknown base %q: %w", p.Name, p.Base, ErrInvalidConfig)
+	}
+
+	if slices.Contains(p.Elements, "") {
+		return fmt.Errorf("policy %q: empty element name: %w", p.Name, ErrInvalidConfig)
+	}
+	for _, attr := range p.Attributes {
+		if attr.Name == "" || slices.Contains(attr.Elements, "") {
+			return fmt.Errorf("policy %q: empty attribute or element name: %w", p.Name, ErrInvalidConfig)
+		}
+	}
+	for _, scheme := range p.URLSchemes {
+		if !urlScheme.MatchString(scheme) {
+			return fmt.Errorf("policy %q: invalid URL scheme %q: %w", p.Name, scheme, ErrInvalidConfig)
+		}
+	}
+	return nil
+}
+
+// urlScheme matches URL schemes as defined by RFC 3986.
+var urlScheme = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*$`)
+
+// build constructs the policy of a valid spec.
+func (p PolicySpec) build() *bluemonday.Policy {
+	policy := bluemonday.StrictPolicy()
+	if p.Base == "ugc" {
+		policy = bluemonday.UGCPolicy()
+	}
+
+	if len(p.Elements) > 0 {
+		policy.AllowElements(p.Elements...)
+	}
+	for _, attr := range p.Attributes {
+		if len(attr.Elements) == 0 {
+			policy.AllowAttrs(attr.Name).Globally()
+			continue
+		}
+		policy.AllowAttrs(attr.Name).OnElements(attr.Elements...)
+	}
+	if len(p.URLSchemes) > 0 {
+		policy.AllowURLSchemes(p.URLSchemes...)
+	}
+	if p.RequireNoopener {
+		policy.AddTargetBlankToFullyQualifiedLinks(true)
+	}
+	return policy
+}
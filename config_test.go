@@ -0,0 +1,231 @@
+package stzr_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/kraciasty/stzr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleConfigure() {
+	var cfg stzr.Config
+	_ = json.Unmarshal([]byte(`{
+		"defaultPolicy": "plain",
+		"policies": [
+			{"name": "plain"},
+			{"name": "notes", "elements": ["abbr", "b"], "attributes": [{"name": "title", "elements": ["abbr"]}]}
+		]
+	}`), &cfg)
+
+	s, err := stzr.Configure(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	type Post struct {
+		Title string
+		Notes string `sanitize:"notes"`
+	}
+	post := &Post{
+		Title: "<b>Hello</b>",
+		Notes: `<abbr title="HyperText" onclick="x()">HTML</abbr> <i>is</i> <b>fun</b>`,
+	}
+	_ = s.SanitizeStruct(post)
+
+	fmt.Println(post.Title)
+	fmt.Println(post.Notes)
+	// Output:
+	// Hello
+	// <abbr title="HyperText">HTML</abbr> is <b>fun</b>
+}
+
+func TestConfigure(t *testing.T) {
+	t.Run("settings", func(t *testing.T) {
+		s, err := stzr.Configure(stzr.Config{
+			TagKey:        "clean",
+			UnknownPolicy: "skip",
+			Policies: []stzr.PolicySpec{
+				{Name: "rich", Base: "ugc", Attributes: []stzr.AttributeSpec{{Name: "class"}}},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "clean", s.TagKey())
+		assert.Equal(t, 1, s.Len())
+
+		input := struct {
+			Body    string `clean:"rich"`
+			Unknown string `clean:"missing"`
+		}{Body: `<p class="x">hi</p><script>x</script>`, Unknown: "<b>kept</b>"}
+		require.NoError(t, s.SanitizeStruct(&input))
+		assert.Equal(t, `<p class="x">hi</p>`, input.Body)
+		assert.Equal(t, "<b>kept</b>", input.Unknown)
+	})
+
+	t.Run("inline options", func(t *testing.T) {
+		s, err := stzr.Configure(stzr.Config{Policies: []stzr.PolicySpec{{Name: "plain"}}})
+		require.NoError(t, err)
+
+		input := struct {
+			Body string `sanitize:"plain;allow=b"`
+		}{Body: "<b>bold</b><i>italic</i>"}
+		require.NoError(t, s.SanitizeStruct(&input))
+		assert.Equal(t, "<b>bold</b>italic", input.Body)
+	})
+
+	tests := []struct {
+		name string
+		cfg  stzr.Config
+		err  error
+	}{
+		{
+			name: "unknown policy mode",
+			cfg:  stzr.Config{UnknownPolicy: "ignore"},
+			err:  stzr.ErrInvalidConfig,
+		},
+		{
+			name: "empty name",
+			cfg:  stzr.Config{Policies: []stzr.PolicySpec{{Base: "ugc"}}},
+			err:  stzr.ErrInvalidPolicyName,
+		},
+		{
+			name: "reserved name",
+			cfg:  stzr.Config{Policies: []stzr.PolicySpec{{Name: "-"}}},
+			err:  stzr.ErrInvalidPolicyName,
+		},
+		{
+			name: "duplicate name",
+			cfg:  stzr.Config{Policies: []stzr.PolicySpec{{Name: "plain"}, {Name: "plain"}}},
+			err:  stzr.ErrInvalidConfig,
+		},
+		{
+			name: "unknown base",
+			cfg:  stzr.Config{Policies: []stzr.PolicySpec{{Name: "plain", Base: "lenient"}}},
+			err:  stzr.ErrInvalidConfig,
+		},
+		{
+			name: "empty element",
+			cfg:  stzr.Config{Policies: []stzr.PolicySpec{{Name: "plain", Elements: []string{""}}}},
+			err:  stzr.ErrInvalidConfig,
+		},
+		{
+			name: "empty attribute",
+			cfg:  stzr.Config{Policies: []stzr.PolicySpec{{Name: "plain", Attributes: []stzr.AttributeSpec{{Elements: []string{"a"}}}}}},
+			err:  stzr.ErrInvalidConfig,
+		},
+		{
+			name: "invalid url scheme",
+			cfg:  stzr.Config{Policies: []stzr.PolicySpec{{Name: "plain", URLSchemes: []string{"java script"}}}},
+			err:  stzr.ErrInvalidConfig,
+		},
+		{
+			name: "unconfigured default policy",
+			cfg:  stzr.Config{DefaultPolicy: "strict"},
+			err:  stzr.ErrInvalidConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := stzr.Configure(tt.cfg)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Nil(t, s)
+		})
+	}
+}
+
+func TestPolicySpec_Build(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  stzr.PolicySpec
+		input string
+		want  string
+	}{
+		{
+			name:  "strict base",
+			spec:  stzr.PolicySpec{},
+			input: `<b>bold</b> <a href="https://example.com">link</a>`,
+			want:  "bold link",
+		},
+		{
+			name:  "ugc base",
+			spec:  stzr.PolicySpec{Base: "ugc"},
+			input: `<b>bold</b><script>x</script>`,
+			want:  "<b>bold</b>",
+		},
+		{
+			name:  "elements",
+			spec:  stzr.PolicySpec{Elements: []string{"b", "i"}},
+			input: `<b title="x">bold</b> <i>italic</i> <u>underline</u>`,
+			want:  "<b>bold</b> <i>italic</i> underline",
+		},
+		{
+			name: "attributes on elements",
+			spec: stzr.PolicySpec{
+				Elements:   []string{"abbr", "b"},
+				Attributes: []stzr.AttributeSpec{{Name: "title", Elements: []string{"abbr"}}},
+			},
+			input: `<abbr title="HyperText">HTML</abbr> <b title="x">bold</b>`,
+			want:  `<abbr title="HyperText">HTML</abbr> <b>bold</b>`,
+		},
+		{
+			name: "global attributes",
+			spec: stzr.PolicySpec{
+				Elements:   []string{"p", "span"},
+				Attributes: []stzr.AttributeSpec{{Name: "lang"}},
+			},
+			input: `<p lang="en">hi</p><span lang="pl" dir="ltr">cześć</span>`,
+			want:  `<p lang="en">hi</p><span lang="pl">cześć</span>`,
+		},
+		{
+			name: "url schemes",
+			spec: stzr.PolicySpec{
+				Attributes: []stzr.AttributeSpec{{Name: "href", Elements: []string{"a"}}},
+				URLSchemes: []string{"https", "mailto"},
+			},
+			input: `<a href="https://example.com">web</a> <a href="mailto:rick@example.com">mail</a> <a href="javascript:alert(1)">js</a> <a href="ftp://example.com">ftp</a>`,
+			want:  `<a href="https://example.com">web</a> <a href="mailto:rick@example.com">mail</a> js ftp`,
+		},
+		{
+			name:  "ugc base with extra scheme",
+			spec:  stzr.PolicySpec{Base: "ugc", URLSchemes: []string{"tel"}},
+			input: `<a href="tel:+48123">call</a>`,
+			want:  `<a href="tel:+48123" rel="nofollow">call</a>`,
+		},
+		{
+			name: "noopener",
+			spec: stzr.PolicySpec{
+				Attributes:      []stzr.AttributeSpec{{Name: "href", Elements: []string{"a"}}},
+				URLSchemes:      []string{"https"},
+				RequireNoopener: true,
+			},
+			input: `<a href="https://example.com">web</a>`,
+			want:  `<a href="https://example.com" target="_blank" rel="noopener">web</a>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := tt.spec.Build()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, policy.Sanitize(tt.input))
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, spec := range []stzr.PolicySpec{
+			{Base: "lenient"},
+			{Elements: []string{""}},
+			{Attributes: []stzr.AttributeSpec{{Name: "title", Elements: []string{""}}}},
+			{URLSchemes: []string{""}},
+			{URLSchemes: []string{"https://"}},
+			{URLSchemes: []string{"1http"}},
+		} {
+			policy, err := spec.Build()
+			assert.ErrorIs(t, err, stzr.ErrInvalidConfig, "%+v", spec)
+			assert.Nil(t, policy)
+		}
+	})
+}
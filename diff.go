@@ -0,0 +1,67 @@
+package stzr
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Diff compares two values of the same type, typically a copy taken before
+// sanitization and the sanitized value, and returns the string values that
+// differ in traversal order. Map entries are compared in the order of their
+// keys. The Policy of the returned changes is empty. Other differences, such
+// as in numbers, slice lengths or map keys present on one side only, are
+// ignored. Values of different types yield no changes.
+func Diff(before, after any) []Change {
+	b, a := reflect.ValueOf(before), reflect.ValueOf(after)
+	if !b.IsValid() || !a.IsValid() || b.Type() != a.Type() {
+		return nil
+	}
+
+	var changes []Change
+	diff(b, a, "", &changes)
+	return changes
+}
+
+func diff(b, a reflect.Value, path string, changes *[]Change) {
+	switch b.Kind() {
+	case reflect.String:
+		if b.String() != a.String() {
+			*changes = append(*changes, Change{Path: path, Before: b.String(), After: a.String()})
+		}
+	case reflect.Struct:
+		rt := b.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			sf := rt.Field(i)
+			if !sf.IsExported() && !isEmbeddedStruct(sf) || !mayHoldStrings(sf.Type.Kind()) {
+				continue
+			}
+			diff(b.Field(i), a.Field(i), joinPath(path, sf.Name), changes)
+		}
+	case reflect.Ptr:
+		if !b.IsNil() && !a.IsNil() {
+			diff(b.Elem(), a.Elem(), path, changes)
+		}
+	case reflect.Interface:
+		if b.IsNil() || a.IsNil() {
+			return
+		}
+		be, ae := b.Elem(), a.Elem()
+		if be.Type() == ae.Type() {
+			diff(be, ae, path, changes)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < min(b.Len(), a.Len()); i++ {
+			diff(b.Index(i), a.Index(i), indexPath(path, i), changes)
+		}
+	case reflect.Map:
+		keys := b.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keyPath("", keys[i]) < keyPath("", keys[j])
+		})
+		for _, key := range keys {
+			if av := a.MapIndex(key); av.IsValid() {
+				diff(b.MapIndex(key), av, keyPath(path, key), changes)
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package stzr_test
+
+import (
+	"testing"
+
+	"github.com/kraciasty/stzr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	type comment struct {
+		Author string `sanitize:"strict"`
+		Text   string `sanitize:"ugc"`
+	}
+	type post struct {
+		Title    string `sanitize:"strict"`
+		Views    int
+		Comments []comment
+		Meta     map[string]string `sanitize:"strict"`
+		Extra    any               `sanitize:"strict"`
+		Parent   *comment
+	}
+
+	newPost := func() post {
+		return post{
+			Title:    "<b>Title</b>",
+			Views:    1,
+			Comments: []comment{{Author: "Rick", Text: "<b>ok</b><script>x</script>"}},
+			Meta:     map[string]string{"b": "<i>b</i>", "a": "<i>a</i>"},
+			Extra:    []any{"<b>x</b>", 1},
+			Parent:   &comment{Author: "<b>Morty</b>"},
+		}
+	}
+
+	before := newPost()
+	after := newPost()
+	require.NoError(t, stzr.Default().SanitizeStruct(&after))
+	after.Views = 2
+
+	assert.Equal(t, []stzr.Change{
+		{Path: "Title", Before: "<b>Title</b>", After: "Title"},
+		{Path: "Comments[0].Text", Before: "<b>ok</b><script>x</script>", After: "<b>ok</b>"},
+		{Path: "Meta[a]", Before: "<i>a</i>", After: "a"},
+		{Path: "Meta[b]", Before: "<i>b</i>", After: "b"},
+		{Path: "Extra[0]", Before: "<b>x</b>", After: "x"},
+		{Path: "Parent.Author", Before: "<b>Morty</b>", After: "Morty"},
+	}, stzr.Diff(before, after))
+
+	t.Run("identical", func(t *testing.T) {
+		assert.Empty(t, stzr.Diff(newPost(), newPost()))
+	})
+
+	t.Run("different types", func(t *testing.T) {
+		assert.Empty(t, stzr.Diff("a", []byte("b")))
+		assert.Empty(t, stzr.Diff(nil, "b"))
+	})
+
+	t.Run("pointers", func(t *testing.T) {
+		b, a := newPost(), newPost()
+		a.Title = "Title"
+		assert.Equal(t, []stzr.Change{{Path: "Title", Before: "<b>Title</b>", After: "Title"}}, stzr.Diff(&b, &a))
+	})
+}
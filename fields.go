@@ -0,0 +1,94 @@
+package stzr
+
+import "reflect"
+
+// FieldInfo describes a field of a struct type that may hold sanitizable
+// strings, as reported by Fields.
+type FieldInfo struct {
+	// Path is the location of the field within the type, e.g.
+	// "Comments[].Author", where "[]" stands for any element of a slice,
+	// array or map.
+	Path string
+	// Tag is the raw sanitization tag of the field, empty when absent.
+	Tag string
+	// Policies are the policy names of the tag in the order they are applied.
+	// For map tags with key and val options, the key policy is followed by
+	// the value policy.
+	Policies []string
+	// Recurses reports whether the walker descends into the value of the
+	// field, i.e. it is not a plain string.
+	Recurses bool
+}
+
+// Fields lists the fields of struct type t, and of the struct types reachable
+// from it through nested structs, pointers, slices, arrays and maps, that may
+// hold sanitizable strings according to the tags under tagKey. Fields tagged
+// with SkipMarker are left out. The walk only considers types, interfaces are
+// reported without descending into them and recursive types are expanded
+// once per path. Tags that fail to parse are reported without policies.
+func Fields(t reflect.Type, tagKey string) []FieldInfo {
+	var fields []FieldInfo
+	collectFields(t, tagKey, "", map[reflect.Type]bool{}, &fields)
+	return fields
+}
+
+func collectFields(t reflect.Type, tagKey, path string, visiting map[reflect.Type]bool, fields *[]FieldInfo) {
+	t = elemStruct(t, &path)
+	if t == nil || visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() && !isEmbeddedStruct(sf) || !mayHoldStrings(sf.Type.Kind()) {
+			continue
+		}
+		tag := sf.Tag.Get(tagKey)
+		if tag == SkipMarker {
+			continue
+		}
+
+		info := FieldInfo{
+			Path:     joinPath(path, sf.Name),
+			Tag:      tag,
+			Recurses: sf.Type.Kind() != reflect.String,
+		}
+		if spec, err := parseTag(tag); tag != "" && err == nil {
+			info.Policies = spec.names
+			if spec.isKeyVal() {
+				info.Policies = nil
+				for _, name := range []string{spec.key, spec.val} {
+					if name != "" {
+						info.Policies = append(info.Policies, name)
+					}
+				}
+			}
+		}
+		*fields = append(*fields, info)
+
+		if info.Recurses {
+			collectFields(sf.Type, tagKey, info.Path, visiting, fields)
+		}
+	}
+}
+
+// elemStruct returns the struct type reached from t through pointers, slices,
+// arrays and maps, appending "[]" to the path for every element, or nil when
+// t does not lead to a struct.
+func elemStruct(t reflect.Type, path *string) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Struct:
+			return t
+		case reflect.Ptr:
+			t = t.Elem()
+		case reflect.Slice, reflect.Array, reflect.Map:
+			*path += "[]"
+			t = t.Elem()
+		default:
+			return nil
+		}
+	}
+}
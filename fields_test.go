@@ -0,0 +1,70 @@
+package stzr_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kraciasty/stzr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFields(t *testing.T) {
+	type author struct {
+		Name string `sanitize:"strict"`
+		Bio  string
+	}
+	type base struct {
+		ID    int
+		Title string `sanitize:"strict,trim"`
+	}
+	type node struct {
+		Label    string `sanitize:"strict"`
+		Children []*node
+	}
+	type post struct {
+		base
+		Body     string `sanitize:"ugc;allow=abbr"`
+		Secret   string `sanitize:"-"`
+		Views    int
+		Author   *author
+		Comments []struct {
+			Text string `sanitize:"ugc"`
+		}
+		Meta  map[string]author `sanitize:"key=strict,val=ugc"`
+		Extra any               `sanitize:"strict"`
+		Tree  node
+		draft string
+	}
+
+	fields := stzr.Fields(reflect.TypeFor[post](), "sanitize")
+	assert.Equal(t, []stzr.FieldInfo{
+		{Path: "base", Recurses: true},
+		{Path: "base.Title", Tag: "strict,trim", Policies: []string{"strict", "trim"}},
+		{Path: "Body", Tag: "ugc;allow=abbr", Policies: []string{"ugc"}},
+		{Path: "Author", Recurses: true},
+		{Path: "Author.Name", Tag: "strict", Policies: []string{"strict"}},
+		{Path: "Author.Bio"},
+		{Path: "Comments", Recurses: true},
+		{Path: "Comments[].Text", Tag: "ugc", Policies: []string{"ugc"}},
+		{Path: "Meta", Tag: "key=strict,val=ugc", Policies: []string{"strict", "ugc"}, Recurses: true},
+		{Path: "Meta[].Name", Tag: "strict", Policies: []string{"strict"}},
+		{Path: "Meta[].Bio"},
+		{Path: "Extra", Tag: "strict", Policies: []string{"strict"}, Recurses: true},
+		{Path: "Tree", Recurses: true},
+		{Path: "Tree.Label", Tag: "strict", Policies: []string{"strict"}},
+		{Path: "Tree.Children", Recurses: true},
+	}, fields)
+
+	t.Run("custom tag key", func(t *testing.T) {
+		type item struct {
+			Name string `clean:"strict"`
+		}
+		assert.Equal(t, []stzr.FieldInfo{
+			{Path: "Name", Tag: "strict", Policies: []string{"strict"}},
+		}, stzr.Fields(reflect.TypeFor[*item](), "clean"))
+	})
+
+	t.Run("non-struct type", func(t *testing.T) {
+		assert.Empty(t, stzr.Fields(reflect.TypeFor[string](), "sanitize"))
+	})
+}
@@ -0,0 +1,256 @@
+package stzr
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SanitizeInto copies src into dst, which must be a pointer to a struct, and
+// sanitizes the result according to the tags of dst. The src value may be a
+// struct or a map with string keys, such as one decoded from JSON into a
+// map[string]any. Fields are matched by their JSON name or by the field
+// name, unmapped fields are skipped, and values that cannot be assigned to
+// the matching field yield an error wrapping ErrTypeMismatch. Structs of an
+// assignable type are copied whole, keeping unexported state such as that of
+// a time.Time. Slices, arrays, maps and pointers are copied, so src is left
+// unchanged.
+func (s *Sanitizer) SanitizeInto(src, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected pointer to struct, got %T", dst)
+	}
+
+	if err := assign(rv.Elem(), reflect.ValueOf(src), ""); err != nil {
+		return err
+	}
+	return s.SanitizeStruct(dst)
+}
+
+// assign copies src into dst, converting between compatible types.
+func assign(dst, src reflect.Value, path string) error {
+	for src.Kind() == reflect.Interface || src.Kind() == reflect.Ptr {
+		if src.IsNil() {
+			return nil
+		}
+		src = src.Elem()
+	}
+	if !src.IsValid() {
+		return nil
+	}
+
+	switch {
+	case dst.Kind() == reflect.Interface:
+		if !src.Type().AssignableTo(dst.Type()) {
+			return mismatch(dst, src, path)
+		}
+		if isCollection(src.Kind()) {
+			// Collections are copied, so sanitizing dst leaves src intact.
+			cp := reflect.New(src.Type()).Elem()
+			if err := assign(cp, src, path); err != nil {
+				return err
+			}
+			src = cp
+		}
+		dst.Set(src)
+		return nil
+	case dst.Kind() == reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assign(dst.Elem(), src, path)
+	case src.Type().AssignableTo(dst.Type()) && dst.Kind() == reflect.Struct:
+		dst.Set(src)
+		return copyFields(dst, src, path)
+	case src.Type().AssignableTo(dst.Type()) && !isCollection(dst.Kind()):
+		dst.Set(src)
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Struct:
+		return assignStruct(dst, src, path)
+	case reflect.Slice:
+		if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+			return mismatch(dst, src, path)
+		}
+		if src.Kind() == reflect.Slice && src.IsNil() {
+			dst.SetZero()
+			return nil
+		}
+		out := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+		for i := range src.Len() {
+			if err := assign(out.Index(i), src.Index(i), indexPath(path, i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(out)
+		return nil
+	case reflect.Array:
+		if src.Kind() != reflect.Slice && src.Kind() != reflect.Array || src.Len() != dst.Len() {
+			return mismatch(dst, src, path)
+		}
+		for i := range src.Len() {
+			if err := assign(dst.Index(i), src.Index(i), indexPath(path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if src.Kind() != reflect.Map {
+			return mismatch(dst, src, path)
+		}
+		if src.IsNil() {
+			dst.SetZero()
+			return nil
+		}
+		out := reflect.MakeMapWithSize(dst.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			key := reflect.New(dst.Type().Key()).Elem()
+			if err := assign(key, iter.Key(), keyPath(path, iter.Key())); err != nil {
+				return err
+			}
+			val := reflect.New(dst.Type().Elem()).Elem()
+			if err := assign(val, iter.Value(), keyPath(path, iter.Key())); err != nil {
+				return err
+			}
+			out.SetMapIndex(key, val)
+		}
+		dst.Set(out)
+		return nil
+	}
+
+	// Scalars convert within their kind family, e.g. float64 decoded from
+	// JSON into an int field, or a string into a named string type.
+	if !sameFamily(dst.Kind(), src.Kind()) || !src.Type().ConvertibleTo(dst.Type()) {
+		return mismatch(dst, src, path)
+	}
+	converted := src.Convert(dst.Type())
+	if converted.Convert(src.Type()).Interface() != src.Interface() {
+		return fmt.Errorf("%s: %v does not fit %s: %w", path, src, dst.Type(), ErrTypeMismatch)
+	}
+	dst.Set(converted)
+	return nil
+}
+
+// copyFields replaces the exported pointers and collections of dst, a struct
+// just set to src, with copies, so sanitizing dst leaves src intact.
+func copyFields(dst, src reflect.Value, path string) error {
+	rt := dst.Type()
+	for i := range rt.NumField() {
+		field := dst.Field(i)
+		var err error
+		switch {
+		case field.Kind() == reflect.Struct:
+			err = copyFields(field, src.Field(i), joinPath(path, rt.Field(i).Name))
+		case field.CanSet() && (field.Kind() == reflect.Ptr || isCollection(field.Kind())):
+			field.SetZero()
+			err = assign(field, src.Field(i), joinPath(path, rt.Field(i).Name))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignStruct copies matching fields of a string-keyed map src or a struct
+// src of another type into the dst struct.
+func assignStruct(dst, src reflect.Value, path string) error {
+	switch src.Kind() {
+	case reflect.Map:
+		if src.Type().Key().Kind() != reflect.String {
+			return mismatch(dst, src, path)
+		}
+	case reflect.Struct:
+	default:
+		return mismatch(dst, src, path)
+	}
+
+	rt := dst.Type()
+	for i := range rt.NumField() {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, ok := fieldName(sf)
+		if !ok {
+			continue
+		}
+
+		var val reflect.Value
+		if src.Kind() == reflect.Map {
+			val = src.MapIndex(reflect.ValueOf(name).Convert(src.Type().Key()))
+		} else {
+			val = lookupField(src, name)
+		}
+		if !val.IsValid() {
+			continue
+		}
+
+		if err := assign(dst.Field(i), val, joinPath(path, sf.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupField returns the field of the struct src matching name.
+func lookupField(src reflect.Value, name string) reflect.Value {
+	rt := src.Type()
+	for i := range rt.NumField() {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		if n, ok := fieldName(sf); ok && n == name {
+			return src.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// fieldName returns the JSON name of a field, falling back to the field
+// name. It reports false for fields excluded from JSON.
+func fieldName(sf reflect.StructField) (string, bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, true
+	}
+	return sf.Name, true
+}
+
+// isCollection reports whether values of kind k hold elements that must be
+// copied one by one.
+func isCollection(k reflect.Kind) bool {
+	return k == reflect.Slice || k == reflect.Array || k == reflect.Map
+}
+
+// sameFamily reports whether values of kinds a and b may be converted into
+// one another without changing their meaning.
+func sameFamily(a, b reflect.Kind) bool {
+	return kindFamily(a) != 0 && kindFamily(a) == kindFamily(b)
+}
+
+func kindFamily(k reflect.Kind) int {
+	switch {
+	case k == reflect.Bool:
+		return 1
+	case k >= reflect.Int && k <= reflect.Float64:
+		return 2
+	case k == reflect.String:
+		return 3
+	}
+	return 0
+}
+
+func mismatch(dst, src reflect.Value, path string) error {
+	if path == "" {
+		return fmt.Errorf("cannot assign %s to %s: %w", src.Type(), dst.Type(), ErrTypeMismatch)
+	}
+	return fmt.Errorf("%s: cannot assign %s to %s: %w", path, src.Type(), dst.Type(), ErrTypeMismatch)
+}
@@ -0,0 +1,169 @@
+package stzr_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kraciasty/stzr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizer_SanitizeInto(t *testing.T) {
+	type author struct {
+		Name string `json:"name" sanitize:"strict"`
+	}
+	type post struct {
+		Title   string            `json:"title" sanitize:"strict"`
+		Body    string            `json:"body" sanitize:"ugc"`
+		Views   int               `json:"views"`
+		Author  *author           `json:"author"`
+		Tags    []string          `json:"tags" sanitize:"strict"`
+		Meta    map[string]string `json:"meta" sanitize:"strict"`
+		Draft   bool
+		Ignored string `json:"-"`
+	}
+
+	tests := []struct {
+		name    string
+		src     any
+		want    post
+		errIs   error
+		wantErr bool
+	}{
+		{
+			name: "from decoded map",
+			src: decode(t, `{
+				"title": "<b>Hello</b>",
+				"body": "<b>World</b><script>x</script>",
+				"views": 42,
+				"author": {"name": "<i>Rick</i>"},
+				"tags": ["<b>a</b>", "b"],
+				"meta": {"k": "<b>v</b>"},
+				"Draft": true,
+				"Ignored": "x",
+				"unknown": "skipped"
+			}`),
+			want: post{
+				Title:  "Hello",
+				Body:   "<b>World</b>",
+				Views:  42,
+				Author: &author{Name: "Rick"},
+				Tags:   []string{"a", "b"},
+				Meta:   map[string]string{"k": "v"},
+				Draft:  true,
+			},
+		},
+		{
+			name: "from struct",
+			src: struct {
+				Title string `json:"title"`
+				Views int64  `json:"views"`
+			}{Title: "<b>Hello</b>", Views: 7},
+			want: post{Title: "Hello", Views: 7},
+		},
+		{
+			name:    "type mismatch",
+			src:     map[string]any{"views": "many"},
+			errIs:   stzr.ErrTypeMismatch,
+			wantErr: true,
+		},
+		{
+			name:    "lossy number",
+			src:     map[string]any{"views": 1.5},
+			errIs:   stzr.ErrTypeMismatch,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported source",
+			src:     []string{"title"},
+			errIs:   stzr.ErrTypeMismatch,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got post
+			err := stzr.Default().SanitizeInto(tt.src, &got)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.errIs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("source unchanged", func(t *testing.T) {
+		src := map[string]any{
+			"tags":  []string{"<b>a</b>"},
+			"meta":  map[string]string{"k": "<b>v</b>"},
+			"extra": []any{map[string]any{"k": "<b>x</b>"}},
+		}
+		var got struct {
+			Tags  []string          `json:"tags" sanitize:"strict"`
+			Meta  map[string]string `json:"meta" sanitize:"strict"`
+			Extra any               `json:"extra" sanitize:"strict"`
+			Codes [1][]string       `json:"codes" sanitize:"strict"`
+		}
+		require.NoError(t, stzr.Default().SanitizeInto(src, &got))
+		assert.Equal(t, []string{"a"}, got.Tags)
+		assert.Equal(t, map[string]string{"k": "v"}, got.Meta)
+		assert.Equal(t, []any{map[string]any{"k": "x"}}, got.Extra)
+		assert.Equal(t, map[string]any{
+			"tags":  []string{"<b>a</b>"},
+			"meta":  map[string]string{"k": "<b>v</b>"},
+			"extra": []any{map[string]any{"k": "<b>x</b>"}},
+		}, src)
+
+		type source struct {
+			Tags  []string    `json:"tags"`
+			Codes [1][]string `json:"codes"`
+			Meta  map[string]string
+		}
+		value := source{Tags: []string{"<i>b</i>"}, Codes: [1][]string{{"<i>c</i>"}}}
+		got.Meta = nil
+		require.NoError(t, stzr.Default().SanitizeInto(value, &got))
+		assert.Equal(t, []string{"b"}, got.Tags)
+		assert.Equal(t, [1][]string{{"c"}}, got.Codes)
+		assert.Nil(t, got.Meta, "nil maps stay nil")
+		assert.Equal(t, source{Tags: []string{"<i>b</i>"}, Codes: [1][]string{{"<i>c</i>"}}}, value)
+	})
+
+	t.Run("assignable structs", func(t *testing.T) {
+		type profile struct {
+			Name string   `sanitize:"strict"`
+			Tags []string `sanitize:"strict"`
+		}
+		type src struct {
+			At     time.Time
+			Author *profile
+		}
+		type dst struct {
+			At     time.Time
+			Author *profile
+		}
+
+		now := time.Now()
+		value := src{At: now, Author: &profile{Name: "<b>Rick</b>", Tags: []string{"<i>a</i>"}}}
+		var got dst
+		require.NoError(t, stzr.Default().SanitizeInto(value, &got))
+		assert.True(t, got.At.Equal(now), "unexported state is kept")
+		assert.Equal(t, &profile{Name: "Rick", Tags: []string{"a"}}, got.Author)
+		assert.Equal(t, &profile{Name: "<b>Rick</b>", Tags: []string{"<i>a</i>"}}, value.Author)
+	})
+
+	t.Run("non-pointer destination", func(t *testing.T) {
+		err := stzr.Default().SanitizeInto(map[string]any{}, post{})
+		assert.ErrorContains(t, err, "expected pointer to struct")
+	})
+}
+
+func decode(t *testing.T, data string) map[string]any {
+	t.Helper()
+	var m map[string]any
+	require.NoError(t, json.Unmarshal([]byte(data), &m))
+	return m
+}
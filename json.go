@@ -0,0 +1,91 @@
+package stzr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// DecodeJSON unmarshals data into v and sanitizes the result.
+func (s *Sanitizer) DecodeJSON(data []byte, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	return s.SanitizeStruct(v)
+}
+
+// SanitizeDecoded sanitizes v, a pointer to a value just decoded by a decoder
+// such as [encoding/xml.Decoder], as SanitizeStruct does. It is the hook point
+// of the decode-then-sanitize pattern, SanitizingDecoder calls it for every
+// value of a JSON stream such as NDJSON. Values allocated by decoders, such
+// as empty maps and slices, nil pointers and interfaces holding decoded
+// documents, are supported.
+func (s *Sanitizer) SanitizeDecoded(v any) error {
+	return s.SanitizeStruct(v)
+}
+
+// SanitizingDecoder wraps a [json.Decoder] and sanitizes every decoded value.
+// It is suited for streams of JSON values such as NDJSON.
+type SanitizingDecoder struct {
+	*json.Decoder
+	s *Sanitizer
+}
+
+// NewDecoder returns a SanitizingDecoder reading from r.
+func (s *Sanitizer) NewDecoder(r io.Reader) *SanitizingDecoder {
+	return &SanitizingDecoder{Decoder: json.NewDecoder(r), s: s}
+}
+
+// Decode reads the next JSON value into v and sanitizes it.
+func (d *SanitizingDecoder) Decode(v any) error {
+	if err := d.Decoder.Decode(v); err != nil {
+		return err
+	}
+	return d.s.SanitizeDecoded(v)
+}
+
+var (
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+	numberType     = reflect.TypeFor[json.Number]()
+)
+
+// sanitizeRawJSON decodes a json.RawMessage, sanitizes the strings it holds
+// with the inherited policy and encodes it back. The message is rewritten
+// only when a string changed, so untouched documents keep their formatting.
+// Numbers are decoded as json.Number to keep their precision and, like
+// json.Number values anywhere, are not sanitized. Messages without a policy
+// to apply are not decoded.
+func (w *walker) sanitizeRawJSON(rv reflect.Value, policy string) error {
+	if policy == "" && w.defaultPolicy == "" || rv.Len() == 0 || !rv.CanSet() {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(rv.Bytes()))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("%s: %w", w.path, err)
+	}
+
+	changed := w.changed
+	w.changed = false
+	defer func() { w.changed = w.changed || changed }()
+
+	if err := w.sanitizeRecursive(reflect.ValueOf(&doc).Elem(), policy); err != nil {
+		return err
+	}
+	if !w.changed || w.dryRun {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("%s: %w", w.path, err)
+	}
+	rv.SetBytes(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return nil
+}
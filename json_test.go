@@ -0,0 +1,281 @@
+package stzr_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kraciasty/stzr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleSanitizingDecoder() {
+	type Comment struct {
+		Author string `json:"author" sanitize:"strict"`
+		Text   string `json:"text" sanitize:"ugc"`
+	}
+
+	input := `{"author":"<b>Rick</b>","text":"<script>x</script><i>Wubba</i>"}
+{"author":"<i>Morty</i>","text":"Aw <b>jeez</b>"}
+`
+
+	dec := stzr.Default().NewDecoder(strings.NewReader(input))
+	for {
+		var c Comment
+		if err := dec.Decode(&c); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			panic(err)
+		}
+		fmt.Printf("%s: %s\n", c.Author, c.Text)
+	}
+
+	// Output:
+	// Rick: <i>Wubba</i>
+	// Morty: Aw <b>jeez</b>
+}
+
+func TestSanitizer_DecodeJSON(t *testing.T) {
+	type payload struct {
+		Name string `json:"name" sanitize:"strict"`
+	}
+
+	type unknown struct {
+		Name string `json:"name" sanitize:"unknown"`
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		target  func() any
+		want    any
+		wantErr bool
+		errIs   error
+	}{
+		{
+			name:   "decodes and sanitizes",
+			data:   `{"name":"<b>Rick</b>"}`,
+			target: func() any { return &payload{} },
+			want:   &payload{Name: "Rick"},
+		},
+		{
+			name:    "invalid json",
+			data:    `{"name":`,
+			target:  func() any { return &payload{} },
+			wantErr: true,
+		},
+		{
+			name:    "sanitization error",
+			data:    `{"name":"Rick"}`,
+			target:  func() any { return &unknown{} },
+			wantErr: true,
+			errIs:   stzr.ErrPolicyNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := tt.target()
+			err := stzr.Default().DecodeJSON([]byte(tt.data), v)
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.errIs != nil {
+					assert.ErrorIs(t, err, tt.errIs)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, v)
+		})
+	}
+}
+
+func TestSanitizingDecoder_Decode(t *testing.T) {
+	type payload struct {
+		Name string `json:"name" sanitize:"strict"`
+	}
+
+	dec := stzr.Default().NewDecoder(strings.NewReader(`{"name":"<b>A</b>"} {"name":"<i>B</i>"} {"name":`))
+
+	var first, second, third payload
+	require.NoError(t, dec.Decode(&first))
+	require.NoError(t, dec.Decode(&second))
+	assert.Error(t, dec.Decode(&third))
+	assert.Equal(t, "A", first.Name)
+	assert.Equal(t, "B", second.Name)
+}
+
+func ExampleSanitizingDecoder_Decode() {
+	type Event struct {
+		User    string `json:"user" sanitize:"strict"`
+		Message string `json:"message" sanitize:"ugc"`
+	}
+
+	stream := strings.NewReader(`{"user":"<b>Rick</b>","message":"Wubba <b>lubba</b><script>x</script>"}
+{"user":"Morty","message":"<i>Aw jeez</i>"}
+`)
+
+	dec := stzr.Default().NewDecoder(stream)
+	for {
+		var event Event
+		if err := dec.Decode(&event); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			panic(err)
+		}
+		fmt.Printf("%s: %s\n", event.User, event.Message)
+	}
+
+	// Output:
+	// Rick: Wubba <b>lubba</b>
+	// Morty: <i>Aw jeez</i>
+}
+
+func ExampleSanitizer_SanitizeDecoded() {
+	type Character struct {
+		Name string `xml:"name" sanitize:"strict"`
+	}
+
+	var character Character
+	input := `<character><name>&lt;b&gt;Rick&lt;/b&gt;</name></character>`
+	if err := xml.NewDecoder(strings.NewReader(input)).Decode(&character); err != nil {
+		panic(err)
+	}
+	if err := stzr.Default().SanitizeDecoded(&character); err != nil {
+		panic(err)
+	}
+	fmt.Println(character.Name)
+
+	// Output: Rick
+}
+
+func TestSanitizer_SanitizeDecoded(t *testing.T) {
+	type item struct {
+		Title string `json:"title" sanitize:"strict"`
+	}
+	type document struct {
+		Items  []item            `json:"items"`
+		Meta   map[string]string `json:"meta" sanitize:"strict"`
+		Extra  map[string]any    `json:"extra" sanitize:"strict"`
+		Parent *item             `json:"parent"`
+		Any    any               `json:"any" sanitize:"strict"`
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want document
+	}{
+		{
+			name: "empty collections",
+			data: `{"items":[],"meta":{},"extra":{},"parent":null,"any":null}`,
+			want: document{Items: []item{}, Meta: map[string]string{}, Extra: map[string]any{}},
+		},
+		{
+			name: "populated",
+			data: `{"items":[{"title":"<b>a</b>"}],"meta":{"k":"<b>v</b>"},"extra":{"n":1,"l":["<b>x</b>"]},"any":{"k":"<b>v</b>"}}`,
+			want: document{
+				Items: []item{{Title: "a"}},
+				Meta:  map[string]string{"k": "v"},
+				Extra: map[string]any{"n": 1.0, "l": []any{"x"}},
+				Any:   map[string]any{"k": "v"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got document
+			require.NoError(t, json.Unmarshal([]byte(tt.data), &got))
+			require.NoError(t, stzr.Default().SanitizeDecoded(&got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSanitizer_RawMessage(t *testing.T) {
+	type document struct {
+		Payload json.RawMessage `sanitize:"strict"`
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "object",
+			payload: `{"title":"<b>Rick</b>","count":12345678901234567890,"tags":["<i>a</i>",null,true]}`,
+			want:    `{"count":12345678901234567890,"tags":["a",null,true],"title":"Rick"}`,
+		},
+		{
+			name:    "bare string",
+			payload: `"<b>Morty</b> & co"`,
+			want:    `"Morty &amp; co"`,
+		},
+		{
+			name:    "unchanged keeps formatting",
+			payload: `{ "title": "plain" }`,
+			want:    `{ "title": "plain" }`,
+		},
+		{
+			name: "empty",
+		},
+		{
+			name:    "invalid",
+			payload: `{"title":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := document{Payload: json.RawMessage(tt.payload)}
+			err := stzr.Default().SanitizeStruct(&doc)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "Payload")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(doc.Payload))
+		})
+	}
+
+	t.Run("untagged", func(t *testing.T) {
+		doc := struct{ Payload json.RawMessage }{Payload: json.RawMessage(`"<b>x</b>"`)}
+		require.NoError(t, stzr.Default().SanitizeStruct(&doc))
+		assert.Equal(t, `"<b>x</b>"`, string(doc.Payload))
+	})
+
+	t.Run("numbers", func(t *testing.T) {
+		redact, err := stzr.Redact(`\d`, "#")
+		require.NoError(t, err)
+		s := stzr.New(stzr.WithPolicy("digits", redact))
+
+		doc := struct {
+			Payload json.RawMessage `sanitize:"digits"`
+		}{Payload: json.RawMessage(`{"code":"C-137","count":42}`)}
+		require.NoError(t, s.SanitizeStruct(&doc))
+		assert.Equal(t, `{"code":"C-###","count":42}`, string(doc.Payload))
+	})
+
+	t.Run("decoded", func(t *testing.T) {
+		type event struct {
+			Payload json.RawMessage `json:"payload" sanitize:"strict"`
+		}
+		var decoded event
+		require.NoError(t, stzr.Default().DecodeJSON([]byte(`{"payload":{"title":"<b>Rick</b>"}}`), &decoded))
+		assert.Equal(t, `{"title":"Rick"}`, string(decoded.Payload))
+
+		dec := stzr.Default().NewDecoder(strings.NewReader(`{"payload":["<i>Morty</i>"]}`))
+		var streamed event
+		require.NoError(t, dec.Decode(&streamed))
+		assert.Equal(t, `["Morty"]`, string(streamed.Payload))
+	})
+}
@@ -0,0 +1,100 @@
+package stzr
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// SanitizeFields applies sanitization like SanitizeStruct, limited to the
+// values at the listed paths, e.g. the fields sent by a client in a partial
+// update. Paths use the grammar of error paths, such as "Title",
+// "Author.Name", "Tags[0]" or "Meta[key]", and include everything nested
+// below them, so "Author" covers "Author.Name". Other values are left
+// untouched, including map keys of maps that are not listed themselves.
+//
+// Paths matching no value, including fields behind nil pointers, are
+// ignored unless ErrorOnUnknownPaths is set with WithUnknownPath, in which
+// case the first of them in the given order yields an error wrapping
+// ErrUnknownPath. Values are sanitized before unknown paths are reported.
+func (s *Sanitizer) SanitizeFields(v any, fieldPaths []string) error {
+	only := newPathSet(fieldPaths)
+	if err := s.walk(v, &walker{Sanitizer: s, only: only}); err != nil {
+		return err
+	}
+
+	if s.unknownPath == ErrorOnUnknownPaths {
+		for _, path := range fieldPaths {
+			if _, ok := only.matched.Load(path); !ok {
+				return fmt.Errorf("%s: %w", path, ErrUnknownPath)
+			}
+		}
+	}
+	return nil
+}
+
+// pathSet holds the paths a walk is limited to.
+type pathSet struct {
+	selected map[string]bool
+	// ancestors holds the paths of values containing selected values, which
+	// are walked without being sanitized themselves.
+	ancestors map[string]bool
+	// matched holds the selected paths reached by the walk, it is shared
+	// with forked walkers.
+	matched sync.Map // string -> struct{}
+}
+
+func newPathSet(paths []string) *pathSet {
+	set := &pathSet{
+		selected:  make(map[string]bool, len(paths)),
+		ancestors: map[string]bool{"": true},
+	}
+	for _, path := range paths {
+		set.selected[path] = true
+		for i := 1; i < len(path); i++ {
+			if path[i] == '.' || path[i] == '[' {
+				set.ancestors[path[:i]] = true
+			}
+		}
+	}
+	return set
+}
+
+// selects reports whether the path is selected or nested below a selected
+// path.
+func (p *pathSet) selects(path []byte) bool {
+	if p.selected[string(path)] {
+		return true
+	}
+	for i := len(path) - 1; i > 0; i-- {
+		if (path[i] == '.' || path[i] == '[') && p.selected[string(path[:i])] {
+			return true
+		}
+	}
+	return false
+}
+
+// includes reports whether the value at the path is walked.
+func (p *pathSet) includes(path []byte) bool {
+	return p.ancestors[string(path)] || p.selects(path)
+}
+
+// visit records the path as reached and reports whether it is walked.
+func (p *pathSet) visit(path []byte) bool {
+	if p.selected[string(path)] {
+		p.matched.Store(string(path), struct{}{})
+		return true
+	}
+	return p.includes(path)
+}
+
+// visitFields records the paths of all fields of a struct as reached, so
+// fields that are never walked, such as numbers or fields tagged with the
+// skip marker, are not reported as unknown.
+func (w *walker) visitFields(rt reflect.Type) {
+	for i := 0; i < rt.NumField(); i++ {
+		n := w.pushField(w.pathName(rt.Field(i)))
+		w.only.visit(w.path)
+		w.pop(n)
+	}
+}
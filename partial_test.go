@@ -0,0 +1,132 @@
+package stzr_test
+
+import (
+	"testing"
+
+	"github.com/kraciasty/stzr"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizer_SanitizeFields(t *testing.T) {
+	type author struct {
+		Name string `sanitize:"strict"`
+		Bio  string `sanitize:"strict"`
+	}
+	type post struct {
+		Title    string            `sanitize:"strict"`
+		Body     string            `sanitize:"ugc"`
+		Views    int               `json:"views"`
+		Secret   string            `sanitize:"-"`
+		Author   *author           `json:"author"`
+		Tags     []string          `sanitize:"strict"`
+		Meta     map[string]string `sanitize:"key=strict,val=strict"`
+		Comments []author
+	}
+	newPost := func() post {
+		return post{
+			Title:    "<b>Title</b>",
+			Body:     "<b>Body</b><script>x</script>",
+			Secret:   "<b>secret</b>",
+			Author:   &author{Name: "<b>Rick</b>", Bio: "<b>Scientist</b>"},
+			Tags:     []string{"<b>a</b>", "<b>b</b>"},
+			Meta:     map[string]string{"<b>k</b>": "<b>v</b>", "x": "<b>y</b>"},
+			Comments: []author{{Name: "<b>Morty</b>"}, {Name: "<b>Summer</b>"}},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  func(*post)
+	}{
+		{
+			name:  "none",
+			paths: nil,
+			want:  func(*post) {},
+		},
+		{
+			name:  "top-level field",
+			paths: []string{"Title"},
+			want:  func(p *post) { p.Title = "Title" },
+		},
+		{
+			name:  "nested field",
+			paths: []string{"Author.Name"},
+			want:  func(p *post) { p.Author.Name = "Rick" },
+		},
+		{
+			name:  "whole struct",
+			paths: []string{"Author"},
+			want:  func(p *post) { p.Author = &author{Name: "Rick", Bio: "Scientist"} },
+		},
+		{
+			name:  "slice element",
+			paths: []string{"Tags[1]", "Comments[0].Name"},
+			want: func(p *post) {
+				p.Tags[1] = "b"
+				p.Comments[0].Name = "Morty"
+			},
+		},
+		{
+			name:  "map value",
+			paths: []string{"Meta[x]"},
+			want:  func(p *post) { p.Meta["x"] = "y" },
+		},
+		{
+			name:  "whole map",
+			paths: []string{"Meta"},
+			want:  func(p *post) { p.Meta = map[string]string{"k": "v", "x": "y"} },
+		},
+		{
+			name:  "skipped and unknown",
+			paths: []string{"Secret", "Missing"},
+			want:  func(*post) {},
+		},
+	}
+
+	s := stzr.New(
+		stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+		stzr.WithPolicy("ugc", bluemonday.UGCPolicy()),
+		stzr.WithRequireTags(),
+	)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, want := newPost(), newPost()
+			tt.want(&want)
+			require.NoError(t, s.SanitizeFields(&input, tt.paths))
+			assert.Equal(t, want, input)
+		})
+	}
+
+	t.Run("path tag", func(t *testing.T) {
+		s := stzr.New(stzr.WithPolicy("strict", bluemonday.StrictPolicy()), stzr.WithPathTag("json"))
+		input := newPost()
+		require.NoError(t, s.SanitizeFields(&input, []string{"author.Name"}))
+		assert.Equal(t, "Rick", input.Author.Name)
+		assert.Equal(t, "<b>Title</b>", input.Title)
+	})
+
+	t.Run("unknown paths", func(t *testing.T) {
+		s := stzr.New(
+			stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+			stzr.WithPolicy("ugc", bluemonday.UGCPolicy()),
+			stzr.WithUnknownPath(stzr.ErrorOnUnknownPaths),
+		)
+
+		input := newPost()
+		require.NoError(t, s.SanitizeFields(&input, []string{"Title", "Views", "Secret", "Tags[0]", "Meta[x]", "Author.Bio"}))
+		assert.Equal(t, "Title", input.Title)
+
+		input = newPost()
+		err := s.SanitizeFields(&input, []string{"Title", "Tags[5]", "Author.Missing"})
+		assert.ErrorIs(t, err, stzr.ErrUnknownPath)
+		assert.EqualError(t, err, "Tags[5]: unknown field path")
+		assert.Equal(t, "Title", input.Title)
+
+		input = newPost()
+		input.Author = nil
+		assert.ErrorIs(t, s.SanitizeFields(&input, []string{"Author.Name"}), stzr.ErrUnknownPath)
+	})
+}
@@ -0,0 +1,89 @@
+package stzr
+
+import (
+	"reflect"
+	"strings"
+)
+
+// fieldPlan describes a struct field that may hold sanitizable strings.
+type fieldPlan struct {
+	index int
+	sf    reflect.StructField
+	tag   string
+	// tagged reports whether the tag is present, even if empty.
+	tagged bool
+	// name is the segment identifying the field in paths.
+	name string
+}
+
+// structPlan returns the cached plan of fields worth visiting for a struct
+// type. Unexported fields and fields that can never hold strings, such as
+// numbers or channels, are left out, so walking a flat struct iterates just
+// its string fields. Embedded unexported structs, or pointers to them, are
+// kept, as their exported fields are settable through the parent. Tagged
+// unexported fields are kept too, so the walk can report the tag as a likely
+// mistake, but they are never sanitized.
+func (s *Sanitizer) structPlan(rt reflect.Type) []fieldPlan {
+	if plan, ok := s.plans.Load(rt); ok {
+		return plan.([]fieldPlan)
+	}
+
+	var plan []fieldPlan
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !mayHoldStrings(sf.Type.Kind()) {
+			continue
+		}
+		tag, tagged := sf.Tag.Lookup(s.tagKey)
+		if !sf.IsExported() && !isEmbeddedStruct(sf) && (!tagged || s.isReserved(tag)) {
+			continue
+		}
+
+		plan = append(plan, fieldPlan{
+			index:  i,
+			sf:     sf,
+			tag:    tag,
+			tagged: tagged,
+			name:   s.pathName(sf),
+		})
+	}
+
+	actual, _ := s.plans.LoadOrStore(rt, plan)
+	return actual.([]fieldPlan)
+}
+
+// pathName returns the name of the field taken from the path tag, falling
+// back to the Go field name when the tag is absent or names no field.
+func (s *Sanitizer) pathName(sf reflect.StructField) string {
+	if s.pathTag == "" {
+		return sf.Name
+	}
+	name, _, _ := strings.Cut(sf.Tag.Get(s.pathTag), ",")
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
+
+// isEmbeddedStruct reports whether the field embeds a struct or a pointer to
+// a struct.
+func isEmbeddedStruct(sf reflect.StructField) bool {
+	if !sf.Anonymous {
+		return false
+	}
+	t := sf.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// mayHoldStrings reports whether values of the kind can contain strings.
+func mayHoldStrings(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Struct, reflect.Ptr, reflect.Slice,
+		reflect.Array, reflect.Map, reflect.Interface:
+		return true
+	}
+	return false
+}
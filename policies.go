@@ -0,0 +1,75 @@
+package stzr
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Escape is a policy that escapes HTML special characters instead of
+// stripping markup, so that `<b>` becomes `&lt;b&gt;` and is rendered as
+// visible text. It suits fields such as code snippets where angle brackets
+// are meaningful.
+var Escape Policy = PolicyFunc(html.EscapeString)
+
+// Unescape is a policy that reverses Escape by unescaping HTML entities.
+//
+// Unescape is NOT a sanitizer: it can turn harmless text into active markup,
+// e.g. `&lt;script&gt;` into `<script>`. Only use it on trusted input or
+// before applying a sanitizing policy.
+var Unescape Policy = PolicyFunc(html.UnescapeString)
+
+// CollapseEmpty is a policy that returns an empty string for input holding
+// only whitespace, including whitespace escaped as HTML entities such as
+// `&nbsp;`, and passes other input through unchanged. Chained after a
+// stripping policy, e.g. `sanitize:"strict,collapse"`, it turns values that
+// look present but are empty once markup is removed into missing values.
+var CollapseEmpty Policy = PolicyFunc(func(s string) string {
+	if strings.TrimSpace(html.UnescapeString(s)) == "" {
+		return ""
+	}
+	return s
+})
+
+// Reject returns a policy refusing input that matches the regular expression
+// pattern, with an error wrapping ErrRejected and carrying msg. Other input
+// is passed through unchanged. Reject validates rather than sanitizes, so it
+// is typically combined with a sanitizing policy. It panics if the pattern
+// does not compile.
+func Reject(pattern string, msg string) Policy {
+	re := regexp.MustCompile(pattern)
+	return PolicyErrFunc(func(s string) (string, error) {
+		if re.MatchString(s) {
+			return "", fmt.Errorf("%w: %s", ErrRejected, msg)
+		}
+		return s, nil
+	})
+}
+
+// Redact returns a policy replacing every match of the regular expression
+// pattern with mask, for removing content such as emails or phone numbers
+// from free text. The mask is inserted literally, `$` is not expanded. The
+// result is built in a buffer from the pool set with WithBufferPool, if any.
+// It returns an error if the pattern does not compile.
+//
+// Redact does not handle markup, so in a chain it belongs after the
+// stripping policy, e.g. `sanitize:"strict,redact"`: matches split by tags
+// such as `rick<b>@</b>citadel.com` are then caught, and masks containing
+// angle brackets are not stripped or escaped by the policies before it.
+func Redact(pattern, mask string) (Policy, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("redact: %w", err)
+	}
+	return BufferedPolicyFunc(func(buf *bytes.Buffer, s string) {
+		last := 0
+		for _, m := range re.FindAllStringIndex(s, -1) {
+			buf.WriteString(s[last:m[0]])
+			buf.WriteString(mask)
+			last = m[1]
+		}
+		buf.WriteString(s[last:])
+	}), nil
+}
@@ -0,0 +1,174 @@
+package stzr_test
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/kraciasty/stzr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleEscape() {
+	s := stzr.New(stzr.WithPolicy("escape", stzr.Escape))
+
+	type Snippet struct {
+		Code string `sanitize:"escape"`
+	}
+	snippet := &Snippet{Code: `<b>"bold"</b>`}
+	_ = s.SanitizeStruct(snippet)
+
+	fmt.Println(snippet.Code)
+	// Output: &lt;b&gt;&#34;bold&#34;&lt;/b&gt;
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		escaped string
+	}{
+		{name: "plain", input: "hello", escaped: "hello"},
+		{name: "markup", input: "<script>alert('x')</script>", escaped: "&lt;script&gt;alert(&#39;x&#39;)&lt;/script&gt;"},
+		{name: "ampersand", input: "a & b", escaped: "a &amp; b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			escaped := stzr.Escape.Sanitize(tt.input)
+			assert.Equal(t, tt.escaped, escaped)
+			assert.Equal(t, tt.input, stzr.Unescape.Sanitize(escaped))
+		})
+	}
+}
+
+func TestCollapseEmpty(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "empty", input: "", want: ""},
+		{name: "spaces", input: "   ", want: ""},
+		{name: "mixed whitespace", input: " \t\r\n\v\f", want: ""},
+		{name: "unicode whitespace", input: "\u00a0\u2003\u3000", want: ""},
+		{name: "entities", input: "&nbsp; &#32;&#x9;", want: ""},
+		{name: "text", input: "  Rick  ", want: "  Rick  "},
+		{name: "entity text", input: "&nbsp;&amp;", want: "&nbsp;&amp;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, stzr.CollapseEmpty.Sanitize(tt.input))
+		})
+	}
+
+	t.Run("chained", func(t *testing.T) {
+		s := stzr.NewWithDefaults(stzr.WithPolicy("collapse", stzr.CollapseEmpty))
+		input := struct {
+			Name string `sanitize:"strict,collapse"`
+			Bio  string `sanitize:"strict,collapse"`
+		}{Name: "<b> </b>\n<script>x</script>", Bio: "<b>Genius</b>"}
+
+		require.NoError(t, s.SanitizeStruct(&input))
+		assert.Equal(t, "", input.Name)
+		assert.Equal(t, "Genius", input.Bio)
+	})
+}
+
+func TestReject(t *testing.T) {
+	s := stzr.New(stzr.WithPolicy("nourls", stzr.Reject(`https?://`, "links are not allowed")))
+
+	type comment struct {
+		Body string `sanitize:"nourls"`
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{name: "allowed", input: "<b>hello</b>"},
+		{name: "rejected", input: "see http://citadel.com", err: `Body: policy "nourls": sanitization input rejected: links are not allowed`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := comment{Body: tt.input}
+			err := s.SanitizeStruct(&input)
+			if tt.err == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tt.input, input.Body)
+				return
+			}
+
+			assert.EqualError(t, err, tt.err)
+			assert.ErrorIs(t, err, stzr.ErrRejected)
+			assert.NotErrorIs(t, err, stzr.ErrPolicyNotFound)
+			assert.Equal(t, tt.input, input.Body)
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	emails, err := stzr.Redact(`[\w.+-]+@[\w-]+\.[\w.]+`, "[REDACTED]")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "no match", input: "wubba lubba dub dub", want: "wubba lubba dub dub"},
+		{name: "single", input: "mail rick@citadel.com now", want: "mail [REDACTED] now"},
+		{name: "multiple", input: "rick@c137.com, morty@c137.com", want: "[REDACTED], [REDACTED]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, emails.Sanitize(tt.input))
+		})
+	}
+
+	t.Run("literal mask", func(t *testing.T) {
+		digits, err := stzr.Redact(`(\d+)`, "$1")
+		require.NoError(t, err)
+		assert.Equal(t, "C-$1", digits.Sanitize("C-137"))
+	})
+
+	t.Run("chained", func(t *testing.T) {
+		s := stzr.NewWithDefaults(stzr.WithPolicy("redact", emails))
+		input := struct {
+			Bio string `sanitize:"strict,redact"`
+		}{Bio: "<i>Reach me at rick<b>@</b>citadel.com</i>"}
+
+		require.NoError(t, s.SanitizeStruct(&input))
+		assert.Equal(t, "Reach me at [REDACTED]", input.Bio)
+	})
+
+	t.Run("buffer pool", func(t *testing.T) {
+		var created int
+		pool := &sync.Pool{New: func() any {
+			created++
+			return new(bytes.Buffer)
+		}}
+		s := stzr.New(stzr.WithPolicy("redact", emails), stzr.WithBufferPool(pool))
+
+		for _, input := range []string{"rick@c137.com", "no match", "morty@c137.com!"} {
+			got, err := s.SanitizeString("redact", input)
+			require.NoError(t, err)
+			assert.Equal(t, emails.Sanitize(input), got)
+		}
+		// The pool may drop buffers at any GC, so reuse is not guaranteed.
+		assert.Positive(t, created, "buffers come from the pool")
+		assert.LessOrEqual(t, created, 3)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		policy, err := stzr.Redact(`[a-`, "***")
+		assert.Error(t, err)
+		assert.Nil(t, policy)
+	})
+}
@@ -0,0 +1,27 @@
+package stzr
+
+import (
+	"database/sql"
+	"reflect"
+)
+
+var (
+	nullStringType = reflect.TypeFor[sql.NullString]()
+	nullType       = reflect.TypeFor[sql.Null[string]]()
+)
+
+// isNullString reports whether t is a nullable string wrapper of
+// database/sql.
+func isNullString(t reflect.Type) bool {
+	return t == nullStringType || t == nullType
+}
+
+// sanitizeNullString sanitizes the string held by a valid nullable string
+// wrapper as if it were the field itself, so paths omit the inner field.
+// Both wrappers hold the string in their first field.
+func (w *walker) sanitizeNullString(rv reflect.Value, policy string) error {
+	if !rv.FieldByName("Valid").Bool() {
+		return nil
+	}
+	return w.sanitizeString(rv.Field(0), policy)
+}
@@ -0,0 +1,74 @@
+package stzr_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/kraciasty/stzr"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizer_NullStringSupport(t *testing.T) {
+	type profile struct {
+		Bio      sql.NullString   `sanitize:"ugc"`
+		Name     sql.Null[string] `sanitize:"strict"`
+		Missing  sql.NullString   `sanitize:"strict"`
+		Nickname *sql.NullString  `sanitize:"strict"`
+		Aliases  []sql.NullString `sanitize:"strict"`
+		Untagged sql.NullString
+	}
+	newProfile := func() profile {
+		return profile{
+			Bio:      sql.NullString{String: "<b>Scientist</b><script>x</script>", Valid: true},
+			Name:     sql.Null[string]{V: "<b>Rick</b>", Valid: true},
+			Missing:  sql.NullString{String: "<b>stale</b>"},
+			Nickname: &sql.NullString{String: "<i>Pickle</i>", Valid: true},
+			Aliases:  []sql.NullString{{String: "<b>C-137</b>", Valid: true}, {String: "<b>null</b>"}},
+			Untagged: sql.NullString{String: "<b>raw</b>", Valid: true},
+		}
+	}
+	policies := []stzr.Opt{
+		stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+		stzr.WithPolicy("ugc", bluemonday.UGCPolicy()),
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		s := stzr.New(append(policies, stzr.WithNullStringSupport())...)
+		input := newProfile()
+		require.NoError(t, s.SanitizeStruct(&input))
+		assert.Equal(t, profile{
+			Bio:      sql.NullString{String: "<b>Scientist</b>", Valid: true},
+			Name:     sql.Null[string]{V: "Rick", Valid: true},
+			Missing:  sql.NullString{String: "<b>stale</b>"},
+			Nickname: &sql.NullString{String: "Pickle", Valid: true},
+			Aliases:  []sql.NullString{{String: "C-137", Valid: true}, {String: "<b>null</b>"}},
+			Untagged: sql.NullString{String: "<b>raw</b>", Valid: true},
+		}, input)
+	})
+
+	t.Run("default policy", func(t *testing.T) {
+		s := stzr.New(append(policies, stzr.WithNullStringSupport(), stzr.WithDefaultPolicy("strict"))...)
+		input := newProfile()
+		require.NoError(t, s.SanitizeStruct(&input))
+		assert.Equal(t, sql.NullString{String: "raw", Valid: true}, input.Untagged)
+	})
+
+	t.Run("error path", func(t *testing.T) {
+		s := stzr.New(stzr.WithNullStringSupport())
+		input := struct {
+			Bio sql.NullString `sanitize:"unknown"`
+		}{Bio: sql.NullString{String: "x", Valid: true}}
+		err := s.SanitizeStruct(&input)
+		assert.ErrorIs(t, err, stzr.ErrPolicyNotFound)
+		assert.ErrorContains(t, err, "Bio:")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s := stzr.New(policies...)
+		input := newProfile()
+		require.NoError(t, s.SanitizeStruct(&input))
+		assert.Equal(t, newProfile(), input)
+	})
+}
@@ -6,36 +6,109 @@
 // of nested structs, slices, maps, generics and pointers.
 //
 // The default sanitizer instance comes with "strict" and "ugc" bluemonday
-// policies and can be configured further.
+// policies, as well as the "escape" policy, and can be configured further.
 package stzr
 
 import (
+	"bytes"
+	"context"
+	"encoding"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
 	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
 	"github.com/microcosm-cc/bluemonday"
 )
 
-const reservedPolicyPanicMsg = `policy name "-" is reserved for skipping sanitization`
+// SkipMarker is the default tag value that excludes a field from
+// sanitization, e.g. `sanitize:"-"`. It cannot be used as a policy name.
+const SkipMarker = "-"
 
-// ErrPolicyNotFound is returned when a requested policy is not found.
-var ErrPolicyNotFound = errors.New("sanitization policy not found")
+// parallelThreshold is the minimum length of a slice or array processed
+// concurrently when parallelism is enabled.
+const parallelThreshold = 256
+
+var (
+	// ErrPolicyNotFound is returned when a requested policy is not found.
+	ErrPolicyNotFound = errors.New("sanitization policy not found")
+	// ErrAliasCycle is returned when policy aliases refer to each other.
+	ErrAliasCycle = errors.New("sanitization policy alias cycle")
+	// ErrInvalidPolicyName is returned when registering a policy under a
+	// reserved or empty name.
+	ErrInvalidPolicyName = errors.New("invalid sanitization policy name")
+	// ErrInvalidTag is returned when a sanitization tag cannot be parsed.
+	ErrInvalidTag = errors.New("invalid sanitization tag")
+	// ErrNotDerivable is returned when tag options are used with a policy
+	// that was not registered with a builder.
+	ErrNotDerivable = errors.New("sanitization policy does not support tag options")
+	// ErrRejected is returned by policies created with Reject when the input
+	// matches a forbidden pattern.
+	ErrRejected = errors.New("sanitization input rejected")
+	// ErrTypeMismatch is returned by SanitizeInto when a source value cannot
+	// be assigned to the matching destination field.
+	ErrTypeMismatch = errors.New("source and destination types mismatch")
+	// ErrTooLarge is returned when a sanitized value exceeds the size limit
+	// set with the max tag option.
+	ErrTooLarge = errors.New("sanitized value too large")
+	// ErrPolicyPanic is returned when WithPanicRecovery is set and a policy
+	// panics.
+	ErrPolicyPanic = errors.New("sanitization policy panicked")
+	// ErrMissingTag is returned when WithRequireTags is set and an exported
+	// string field carries no sanitization tag.
+	ErrMissingTag = errors.New("missing sanitization tag")
+	// ErrUnknownPath is returned by SanitizeFields when a listed path matches
+	// no value and ErrorOnUnknownPaths is set.
+	ErrUnknownPath = errors.New("unknown field path")
+	// ErrEmptied is returned when sanitization empties a non-empty field
+	// tagged with the required option.
+	ErrEmptied = errors.New("required value emptied by sanitization")
+	// ErrInvalidConfig is returned by Configure when the configuration is
+	// invalid.
+	ErrInvalidConfig = errors.New("invalid sanitizer configuration")
+)
+
+// PolicyNotFoundError is returned when a policy referenced by name is not
+// registered. It unwraps to ErrPolicyNotFound.
+type PolicyNotFoundError struct {
+	// Name is the requested policy name.
+	Name string
+	// Path is the location of the field that requested the policy, it is
+	// empty when the policy was requested directly.
+	Path string
+}
+
+func (e *PolicyNotFoundError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("policy %q: %v", e.Name, ErrPolicyNotFound)
+	}
+	return fmt.Sprintf("%s: policy %q: %v", e.Path, e.Name, ErrPolicyNotFound)
+}
+
+// Unwrap returns ErrPolicyNotFound.
+func (e *PolicyNotFoundError) Unwrap() error {
+	return ErrPolicyNotFound
+}
 
 var defaultSanitizer atomic.Pointer[Sanitizer]
 
 func init() {
-	defaultSanitizer.Store(New(
-		WithPolicy("strict", bluemonday.StrictPolicy()),
-		WithPolicy("ugc", bluemonday.UGCPolicy()),
-	))
+	defaultSanitizer.Store(NewWithDefaults())
 }
 
-// Default returns the default Sanitizer. By default, it includes "ugc" and
-// "strict" policies that match the bluemonday UGC and Strict policies, but
-// the global instance may be modified by calling SetDefault.
+// Default returns the default Sanitizer. By default, it is created with
+// NewWithDefaults, but the global instance may be replaced by calling
+// SetDefault.
 func Default() *Sanitizer { return defaultSanitizer.Load() }
 
 // SetDefault sets the default Sanitizer used by the package-level functions.
@@ -43,6 +116,20 @@ func SetDefault(s *Sanitizer) {
 	defaultSanitizer.Store(s)
 }
 
+// UpdateDefault replaces the default Sanitizer with the one returned by fn,
+// retrying with the latest default when it was replaced concurrently, so
+// that concurrent updates compose. fn may be called multiple times and
+// should return a new instance, e.g. derived using Clone, rather than
+// modify the one it is given.
+func UpdateDefault(fn func(*Sanitizer) *Sanitizer) {
+	for {
+		current := defaultSanitizer.Load()
+		if defaultSanitizer.CompareAndSwap(current, fn(current)) {
+			return
+		}
+	}
+}
+
 // SanitizeString applies sanitization using the default sanitizer.
 func SanitizeString(policy string, input string) (string, error) {
 	return Default().SanitizeString(policy, input)
@@ -66,11 +153,249 @@ func (f PolicyFunc) Sanitize(s string) string {
 	return f(s)
 }
 
+// CheckedPolicy is a Policy able to reject input. Sanitization stops with a
+// FieldError when a field is rejected.
+type CheckedPolicy interface {
+	Policy
+	SanitizeChecked(s string) (string, error)
+}
+
+// PolicyErrFunc is a function type that implements the CheckedPolicy
+// interface, for policies that reject input rather than clean it.
+type PolicyErrFunc func(s string) (string, error)
+
+// Sanitize implements the Policy interface for PolicyErrFunc. Rejected input
+// is replaced with an empty string.
+func (f PolicyErrFunc) Sanitize(s string) string {
+	out, err := f(s)
+	if err != nil {
+		return ""
+	}
+	return out
+}
+
+// SanitizeChecked implements the CheckedPolicy interface for PolicyErrFunc.
+func (f PolicyErrFunc) SanitizeChecked(s string) (string, error) {
+	return f(s)
+}
+
+// ContextPolicy is a Policy aware of the request context, e.g. to sanitize
+// depending on the tenant or locale. SanitizeStructContext passes its context
+// to policies implementing it, other calls use Sanitize.
+type ContextPolicy interface {
+	Policy
+	SanitizeContext(ctx context.Context, s string) string
+}
+
+// BufferedPolicy is a Policy building its output in a buffer. The buffer is
+// drawn from the pool set with WithBufferPool, when there is one, instead of
+// being allocated for every value. SanitizeTo receives an empty buffer, which
+// must not be retained.
+type BufferedPolicy interface {
+	Policy
+	SanitizeTo(buf *bytes.Buffer, s string)
+}
+
+// BufferedPolicyFunc is a function type that implements the BufferedPolicy
+// interface, writing the sanitized s to buf.
+type BufferedPolicyFunc func(buf *bytes.Buffer, s string)
+
+// Sanitize implements the Policy interface for BufferedPolicyFunc, writing
+// to a new buffer.
+func (f BufferedPolicyFunc) Sanitize(s string) string {
+	var buf bytes.Buffer
+	f(&buf, s)
+	return buf.String()
+}
+
+// SanitizeTo implements the BufferedPolicy interface for BufferedPolicyFunc.
+func (f BufferedPolicyFunc) SanitizeTo(buf *bytes.Buffer, s string) {
+	f(buf, s)
+}
+
+// FieldError is returned when a checked policy rejects a field value.
+type FieldError struct {
+	// Path is the location of the rejected field.
+	Path string
+	// Policy is the name of the policy that rejected the value.
+	Policy string
+	// Err is the error returned by the policy.
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: policy %q: %v", e.Path, e.Policy, e.Err)
+}
+
+// Unwrap returns the error returned by the policy.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// SizeError is returned when a sanitized field exceeds the limit set with the
+// max tag option, e.g. `sanitize:"strict;max=1000"`, or when a field exceeds
+// the limit set with WithMaxFieldSize before sanitization. It unwraps to
+// ErrTooLarge.
+type SizeError struct {
+	// Path is the location of the oversized field.
+	Path string
+	// Size is the size of the value in bytes.
+	Size int
+	// Limit is the maximum size in bytes.
+	Limit int
+}
+
+func (e *SizeError) Error() string {
+	return fmt.Sprintf("%s: %d bytes exceeds limit of %d: %v", e.Path, e.Size, e.Limit, ErrTooLarge)
+}
+
+// Unwrap returns ErrTooLarge.
+func (e *SizeError) Unwrap() error {
+	return ErrTooLarge
+}
+
+// Sanitizable is implemented by named string types that sanitize themselves,
+// e.g. normalizing a slug. Sanitize is called with a pointer to the value
+// after the policy from the field tag, or the default policy, was applied,
+// so both take effect. Tagging the field with the skip marker skips both.
+type Sanitizable interface {
+	Sanitize(s *Sanitizer) error
+}
+
+// FieldHook is called for every field a policy is applied to. The path is
+// the dotted location of the field within the sanitized value, e.g.
+// "Comments[0].Author".
+type FieldHook func(path, policy, before, after string)
+
+// PolicyResolver selects a policy for a struct field at runtime. The parent
+// is the struct value holding the field, which allows choosing a policy based
+// on sibling fields. Returning false falls back to the field's tag.
+type PolicyResolver func(sf reflect.StructField, parent reflect.Value) (policy string, ok bool)
+
+// Observer receives metrics about policies applied during struct
+// sanitization, e.g. to export them to a monitoring system. Its methods may
+// be called concurrently when parallelism is enabled.
+type Observer interface {
+	// FieldSanitized is called after the policy was applied to a value,
+	// with the number of bytes it removed.
+	FieldSanitized(policy string, removed int)
+	// Duration is called with the time it took to apply the policy.
+	Duration(policy string, d time.Duration)
+}
+
+// UnknownPolicyMode selects how fields tagged with unregistered policies are
+// handled.
+type UnknownPolicyMode int
+
+const (
+	// ErrorOnUnknown stops sanitization with a PolicyNotFoundError.
+	ErrorOnUnknown UnknownPolicyMode = iota
+	// SkipOnUnknown leaves the field untouched. The field hook, if any, is
+	// still called with the unknown policy name and the unchanged value.
+	SkipOnUnknown
+)
+
+// UnknownPathMode selects how paths passed to SanitizeFields that match no
+// value are handled.
+type UnknownPathMode int
+
+const (
+	// IgnoreUnknownPaths skips paths matching no value.
+	IgnoreUnknownPaths UnknownPathMode = iota
+	// ErrorOnUnknownPaths fails with an error wrapping ErrUnknownPath.
+	ErrorOnUnknownPaths
+)
+
+// OversizeMode selects how fields exceeding the limit set with
+// WithMaxFieldSize are handled.
+type OversizeMode int
+
+const (
+	// ErrorOnOversize stops sanitization with a SizeError.
+	ErrorOnOversize OversizeMode = iota
+	// TruncateOversize truncates the field to the limit, without splitting
+	// UTF-8 encoded characters, before applying the policy.
+	TruncateOversize
+)
+
 // Sanitizer provides configurable HTML sanitization based on struct tags.
 type Sanitizer struct {
+	config
+	*registry
+	plans sync.Map // reflect.Type -> []fieldPlan
+	tags  sync.Map // string -> parsedTag
+}
+
+// registry holds the policies of a Sanitizer, shared with its scoped views.
+type registry struct {
 	mu       sync.RWMutex
-	tagKey   string
 	policies map[string]Policy
+	aliases  map[string]string
+	builders map[string]builder
+	derived  map[string]Policy
+	// registered lists the names of policies registered by options, in
+	// order, so that New can fold names registered before
+	// WithCaseInsensitivePolicies. It is cleared once New returns.
+	registered []string
+	// types holds the handlers registered with RegisterType. The map is
+	// replaced on registration, so the walk reads it without locking.
+	types atomic.Pointer[map[reflect.Type]TypeHandler]
+}
+
+// config holds the settings of a Sanitizer that are fixed after New.
+type config struct {
+	tagKey    string
+	fieldHook FieldHook
+	// changeCh receives the changes made during struct sanitization.
+	changeCh chan<- Change
+	// defaultPolicy is applied to string fields without a tag.
+	defaultPolicy string
+	resolver      PolicyResolver
+	parallelism   int
+	// reserved holds names that cannot be registered as policies, tags
+	// using them exclude a field from sanitization.
+	reserved map[string]struct{}
+	// skipMarker is the reserved name replaced by WithSkipMarker.
+	skipMarker string
+	skipFunc   func(reflect.Type) bool
+	// pathTag is the tag naming fields in paths, the Go field name is used
+	// when empty.
+	pathTag       string
+	unknownPolicy UnknownPolicyMode
+	unknownPath   UnknownPathMode
+	bufferPool    *sync.Pool
+	// shallow limits descending to fields carrying a tag.
+	shallow  bool
+	observer Observer
+	// requireTags rejects exported string fields without a tag.
+	requireTags bool
+	// foldCase makes policy and alias names case-insensitive.
+	foldCase bool
+	// scope prefixes policy names looked up by views from WithScope.
+	scope string
+	// idempotent visits values shared by pointers once per walk.
+	idempotent bool
+	// logger receives debug logs of the walker, it may be nil.
+	logger *slog.Logger
+	// textMarshalers sanitizes tagged text marshalers as text.
+	textMarshalers bool
+	// nullStrings sanitizes valid sql.NullString values as strings.
+	nullStrings bool
+	// maxFieldSize limits the size of fields passed to policies, zero when
+	// unlimited.
+	maxFieldSize int
+	oversize     OversizeMode
+	// keyPolicy sanitizes keys of free-form maps without a key policy.
+	keyPolicy string
+	// recoverPanics converts panics of policies into errors.
+	recoverPanics bool
+}
+
+// builder constructs a bluemonday policy registered with WithPolicyBuilder.
+type builder struct {
+	build func() *bluemonday.Policy
+	// policy is the registered instance, the builder only applies to it.
+	policy *bluemonday.Policy
 }
 
 // Opt defines a functional option type for configuring the Sanitizer.
@@ -80,29 +405,193 @@ type Opt func(*Sanitizer)
 // Use functional options to configure the sanitizer's behavior.
 func New(opts ...Opt) *Sanitizer {
 	s := &Sanitizer{
-		tagKey:   "sanitize",
-		policies: make(map[string]Policy),
+		config: config{
+			tagKey:      "sanitize",
+			parallelism: 1,
+			reserved:    map[string]struct{}{SkipMarker: {}},
+			skipMarker:  SkipMarker,
+		},
+		registry: &registry{
+			policies: make(map[string]Policy),
+			aliases:  make(map[string]string),
+			builders: make(map[string]builder),
+			derived:  make(map[string]Policy),
+		},
 	}
 
 	for _, opt := range opts {
 		opt(s)
 	}
+	if s.foldCase {
+		s.foldRegistered()
+	}
+	s.registered = nil
 
 	return s
 }
 
+// foldRegistered lowercases the names of policies registered by options
+// preceding WithCaseInsensitivePolicies. Names differing only in case are
+// folded in registration order, so the last registration wins.
+func (s *Sanitizer) foldRegistered() {
+	policies := make(map[string]Policy, len(s.policies))
+	builders := make(map[string]builder, len(s.builders))
+	for _, name := range s.registered {
+		folded := strings.ToLower(name)
+		policies[folded] = s.policies[name]
+		if b, ok := s.builders[name]; ok {
+			builders[folded] = b
+		} else {
+			delete(builders, folded)
+		}
+	}
+	s.policies, s.builders = policies, builders
+}
+
+// Clone returns an independent copy of the Sanitizer with the same settings,
+// policies and aliases. Changes to the copy do not affect the original.
+func (s *Sanitizer) Clone() *Sanitizer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	clone := &Sanitizer{
+		config: s.config,
+		registry: &registry{
+			policies: maps.Clone(s.policies),
+			aliases:  maps.Clone(s.aliases),
+			builders: maps.Clone(s.builders),
+			derived:  maps.Clone(s.derived),
+		},
+	}
+	clone.types.Store(s.types.Load())
+	return clone
+}
+
+// WithScope returns a view of the Sanitizer resolving policy names within
+// the scope first, e.g. a tag referencing "ugc" selects the "tenantA:ugc"
+// policy in the "tenantA" scope, falling back to "ugc" when the scoped name
+// is not registered. The view shares the policies and aliases of s, so
+// registering a policy through either affects both.
+func (s *Sanitizer) WithScope(scope string) *Sanitizer {
+	cfg := s.config
+	cfg.scope = scope
+	return &Sanitizer{config: cfg, registry: s.registry}
+}
+
+// scoped returns the normalized name of a policy, prefixed with the scope
+// when the scoped policy or alias is registered.
+func (s *Sanitizer) scoped(name string) string {
+	name = s.policyName(name)
+	if s.scope == "" {
+		return name
+	}
+
+	scoped := s.policyName(s.scope + ":" + name)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.policies[scoped]; ok {
+		return scoped
+	}
+	if _, ok := s.aliases[scoped]; ok {
+		return scoped
+	}
+	return name
+}
+
+// NewWithDefaults creates a new Sanitizer preloaded with the following
+// policies, then applies the given options, which may override them:
+//
+//   - "strict": [bluemonday.StrictPolicy], supporting inline tag options
+//   - "ugc": [bluemonday.UGCPolicy], supporting inline tag options
+//   - "escape": [Escape]
+//
+// The strict and ugc policies are idempotent, applying them to their own
+// output leaves it unchanged. Escape is not, it escapes its output again.
+func NewWithDefaults(opts ...Opt) *Sanitizer {
+	defaults := []Opt{
+		WithPolicyBuilder("strict", bluemonday.StrictPolicy),
+		WithPolicyBuilder("ugc", bluemonday.UGCPolicy),
+		WithPolicy("escape", Escape),
+	}
+	return New(append(defaults, opts...)...)
+}
+
+// DefaultWith creates a new Sanitizer with the policies of NewWithDefaults,
+// letting modify tweak fresh copies of the ugc and strict policies, e.g. to
+// additionally allow links opening in a new tab. The default Sanitizer is
+// left untouched. Inline tag options keep working, modify is applied to the
+// policies derived from them too.
+func DefaultWith(modify func(ugc, strict *bluemonday.Policy)) *Sanitizer {
+	build := func(pick func(ugc, strict *bluemonday.Policy) *bluemonday.Policy) func() *bluemonday.Policy {
+		return func() *bluemonday.Policy {
+			ugc, strict := bluemonday.UGCPolicy(), bluemonday.StrictPolicy()
+			modify(ugc, strict)
+			return pick(ugc, strict)
+		}
+	}
+	return NewWithDefaults(
+		WithPolicyBuilder("strict", build(func(_, strict *bluemonday.Policy) *bluemonday.Policy { return strict })),
+		WithPolicyBuilder("ugc", build(func(ugc, _ *bluemonday.Policy) *bluemonday.Policy { return ugc })),
+	)
+}
+
 // WithPolicy adds a custom sanitization policy to the Sanitizer.
-// The name "-" is reserved and cannot be used as a policy name.
+// Reserved names, such as the skip marker, cannot be used as policy names.
 func WithPolicy(name string, policy Policy) Opt {
 	return func(s *Sanitizer) {
-		if name == "-" {
-			panic(reservedPolicyPanicMsg)
-		}
+		s.mustNotBeReserved(name)
+
+		name = s.policyName(name)
+		s.policies[name] = policy
+		delete(s.builders, name)
+		s.registered = append(s.registered, name)
+	}
+}
+
+// WithPolicyBuilder adds a bluemonday policy constructed by build. Unlike
+// WithPolicy, tags referencing the policy may tweak it with inline options,
+// e.g. `sanitize:"ugc;allow=abbr,allow=cite"`, as the builder is used to
+// construct derived policies. Derived policies are built once per unique tag.
+// Reserved names, such as the skip marker, cannot be used as policy names.
+func WithPolicyBuilder(name string, build func() *bluemonday.Policy) Opt {
+	return func(s *Sanitizer) {
+		s.mustNotBeReserved(name)
 
+		name = s.policyName(name)
+		policy := build()
 		s.policies[name] = policy
+		s.builders[name] = builder{build: build, policy: policy}
+		s.registered = append(s.registered, name)
 	}
 }
 
+// WithPolicyFactory adds a policy constructed by factory on first use, which
+// suits policies that are expensive to build and rarely needed. The factory
+// is called at most once, concurrent first uses wait for it to return.
+// Reserved names, such as the skip marker, cannot be used as policy names.
+func WithPolicyFactory(name string, factory func() Policy) Opt {
+	return WithPolicy(name, &lazyPolicy{factory: factory})
+}
+
+// lazyPolicy is a policy registered with WithPolicyFactory. Lookups unwrap
+// it, so the constructed policy is used directly.
+type lazyPolicy struct {
+	once    sync.Once
+	factory func() Policy
+	policy  Policy
+}
+
+func (p *lazyPolicy) get() Policy {
+	p.once.Do(func() {
+		p.policy = p.factory()
+	})
+	return p.policy
+}
+
+// Sanitize implements Policy for lazy policies obtained from Snapshot.
+func (p *lazyPolicy) Sanitize(s string) string {
+	return p.get().Sanitize(s)
+}
+
 // WithTagKey sets the tag key used for sanitization policies.
 func WithTagKey(key string) Opt {
 	return func(s *Sanitizer) {
@@ -110,181 +599,1630 @@ func WithTagKey(key string) Opt {
 	}
 }
 
-// Add allows adding custom sanitizers to this instance.
-// The name "-" is reserved and cannot be used as a policy name.
-func (s *Sanitizer) Add(name string, policy *bluemonday.Policy) {
-	if name == "-" {
-		panic(reservedPolicyPanicMsg)
+// WithFieldHook sets a hook invoked after a policy is applied to a field.
+// The hook is called without holding any locks, so it may safely call back
+// into the Sanitizer.
+func WithFieldHook(hook FieldHook) Opt {
+	return func(s *Sanitizer) {
+		s.fieldHook = hook
 	}
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.policies[name] = policy
 }
 
-// Remove a sanitizer policy by name.
-func (s *Sanitizer) Remove(name string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	delete(s.policies, name)
+// WithChangeChannel publishes every change struct sanitization makes to a
+// field to ch, e.g. to feed a dashboard of stripped content. Unlike a field
+// hook, the walk never waits for the consumer: changes are sent without
+// blocking and dropped when the channel is full, so a buffered channel is
+// advisable. Unchanged fields and dry runs are not published. A nil channel
+// publishes nothing.
+func WithChangeChannel(ch chan<- Change) Opt {
+	return func(s *Sanitizer) {
+		s.changeCh = ch
+	}
 }
 
-// SanitizeString applies sanitization based on the given policy name.
-func (s *Sanitizer) SanitizeString(policy string, input string) (string, error) {
-	s.mu.RLock()
-	p, ok := s.policies[policy]
-	s.mu.RUnlock()
-	if !ok {
-		return "", fmt.Errorf("policy %q: %w", policy, ErrPolicyNotFound)
+// WithDefaultPolicy sets a policy applied to string fields that lack a
+// sanitization tag. Fields tagged with the skip marker are still skipped,
+// and explicitly tagged fields keep their own policy.
+//
+// A tag may be in one of three states:
+//
+//   - absent: strings get the default policy, if any
+//   - empty, e.g. `sanitize:""`: the field explicitly uses the default
+//     policy, which satisfies WithRequireTags and makes WithShallow descend
+//     into the field
+//   - the skip marker, e.g. `sanitize:"-"`: the field is skipped
+func WithDefaultPolicy(name string) Opt {
+	return func(s *Sanitizer) {
+		s.defaultPolicy = name
 	}
+}
 
-	return p.Sanitize(input), nil
+// WithPolicyResolver sets a resolver consulted for every struct field during
+// the walk. When it reports ok, the returned policy overrides the static tag.
+func WithPolicyResolver(resolver PolicyResolver) Opt {
+	return func(s *Sanitizer) {
+		s.resolver = resolver
+	}
 }
 
-// SanitizeStruct applies sanitization based on struct tags.
-func (s *Sanitizer) SanitizeStruct(v any) error {
-	if v == nil {
-		return nil
+// WithSkipMarker sets the tag value that excludes a field from sanitization,
+// SkipMarker by default. It is useful when "-" already has a meaning for
+// another library sharing the tag key. The marker cannot be used as a policy
+// name, so it should be set before registering policies.
+func WithSkipMarker(marker string) Opt {
+	return func(s *Sanitizer) {
+		delete(s.reserved, s.skipMarker)
+		s.reserved[marker] = struct{}{}
+		s.skipMarker = marker
 	}
+}
 
-	rv := reflect.ValueOf(v)
-	if rv.Kind() != reflect.Ptr || rv.IsNil() {
-		return fmt.Errorf("expected pointer to struct, got %T", v)
+// WithReservedNames replaces the set of reserved names, which is {"-"} by
+// default. Reserved names cannot be used as policy names, and tags using
+// them exclude a field from sanitization. Calling it without names frees
+// "-" for use as a policy name. It replaces any marker set by an earlier
+// WithSkipMarker, so it should be passed before registering policies.
+func WithReservedNames(names ...string) Opt {
+	return func(s *Sanitizer) {
+		s.reserved = make(map[string]struct{}, len(names))
+		for _, name := range names {
+			s.reserved[name] = struct{}{}
+		}
 	}
+}
 
-	elem := rv.Elem()
-	if !elem.IsValid() || elem.IsZero() {
-		return nil
+// WithSkipFunc sets a predicate consulted for the type of every value
+// visited during the walk. Values of types it reports true for, including
+// everything they hold, are left untouched.
+func WithSkipFunc(skip func(reflect.Type) bool) Opt {
+	return func(s *Sanitizer) {
+		s.skipFunc = skip
 	}
+}
 
-	return s.sanitizeRecursive(elem)
+// WithPathTag sets the tag supplying field names in paths reported by errors,
+// hooks and dry runs, e.g. "json" to match the names seen by API clients.
+// Fields without the tag fall back to the Go field name, which is also the
+// default for all fields.
+func WithPathTag(key string) Opt {
+	return func(s *Sanitizer) {
+		s.pathTag = key
+	}
 }
 
-func (s *Sanitizer) sanitizeRecursive(rv reflect.Value) error {
-	if !rv.IsValid() || rv.IsZero() {
-		return nil
+// WithUnknownPolicy sets how fields tagged with unregistered policies are
+// handled during struct sanitization. The default is ErrorOnUnknown.
+func WithUnknownPolicy(mode UnknownPolicyMode) Opt {
+	return func(s *Sanitizer) {
+		s.unknownPolicy = mode
 	}
+}
 
-	switch rv.Kind() {
-	case reflect.Struct:
-		return s.sanitizeStruct(rv)
-	case reflect.Ptr:
-		return s.sanitizePointer(rv)
-	case reflect.Slice, reflect.Array:
-		return s.sanitizeSliceOrArray(rv)
-	case reflect.Map:
-		return s.sanitizeMap(rv)
-	case reflect.Interface:
-		return s.sanitizeInterface(rv)
+// WithUnknownPath sets how paths passed to SanitizeFields that match no value
+// are handled. The default is IgnoreUnknownPaths.
+func WithUnknownPath(mode UnknownPathMode) Opt {
+	return func(s *Sanitizer) {
+		s.unknownPath = mode
 	}
+}
 
-	return nil
+// WithBufferPool sets a pool of *bytes.Buffer values used for intermediate
+// buffers, reducing allocations in high-throughput services. Policies
+// implementing BufferedPolicy, such as those built with BufferedPolicyFunc
+// and the built-in Redact, write their output to buffers drawn from it, and
+// SanitizeReader draws from it when buffering input for policies that cannot
+// stream. Other built-in policies do not build their output in buffers. The
+// pool may return nil or other types, in which case a new buffer is
+// allocated.
+func WithBufferPool(pool *sync.Pool) Opt {
+	return func(s *Sanitizer) {
+		s.bufferPool = pool
+	}
 }
 
-// sanitizeStruct processes struct fields and applies sanitization based on tags
-func (s *Sanitizer) sanitizeStruct(rv reflect.Value) error {
-	rt := rv.Type()
-	for i := 0; i < rv.NumField(); i++ {
-		field := rv.Field(i)
-		sf := rt.Field(i)
+// WithShallow makes the walker descend only into nested structs, pointers,
+// slices, maps and interfaces held by fields carrying a tag. Untagged string
+// fields are still subject to the default policy. This suits flat DTOs,
+// where walking untagged nested values is wasted work.
+func WithShallow() Opt {
+	return func(s *Sanitizer) {
+		s.shallow = true
+	}
+}
 
-		if !field.CanSet() {
-			continue
-		}
+// WithRequireTags makes struct sanitization fail with ErrMissingTag on any
+// exported string field without a tag, forcing an explicit policy or skip
+// marker on every such field. A policy chosen by the resolver counts as a
+// tag, as does an empty tag selecting the default policy.
+func WithRequireTags() Opt {
+	return func(s *Sanitizer) {
+		s.requireTags = true
+	}
+}
 
-		if err := s.sanitizeField(field, sf); err != nil {
-			return err
-		}
+// WithCaseInsensitivePolicies makes policy and alias names case-insensitive,
+// so that tags referencing "UGC" and "ugc" select the same policy. Names are
+// normalized both on registration, where differently cased names replace
+// each other with the last registration winning, including names registered
+// by preceding options, and on lookup by SanitizeString and struct tags.
+func WithCaseInsensitivePolicies() Opt {
+	return func(s *Sanitizer) {
+		s.foldCase = true
 	}
-	return nil
 }
 
-// sanitizeField handles individual field sanitization
-func (s *Sanitizer) sanitizeField(field reflect.Value, sf reflect.StructField) error {
-	tag := sf.Tag.Get(s.tagKey)
-	if tag == "-" {
-		return nil
+// WithIdempotencyCache makes struct sanitization visit a value reached through
+// several pointers once per policy in a single call, instead of once per
+// pointer. This matters for policies that are not idempotent, such as
+// "escape", which would otherwise escape a shared value repeatedly.
+func WithIdempotencyCache() Opt {
+	return func(s *Sanitizer) {
+		s.idempotent = true
 	}
+}
 
-	if tag != "" && field.Kind() == reflect.String {
-		return s.applySanitizationPolicy(field, tag)
+// WithLogger sets a logger receiving debug logs about struct sanitization,
+// such as skipped fields, unknown policies skipped with SkipOnUnknown and the
+// depth of nested structs. Unexported fields carrying a sanitization tag,
+// which can never be applied, are reported as skipped with the reason
+// "tagged but unexported", which helps catching fields lowercased by
+// mistake. Logs are only built when the logger is enabled for the debug
+// level. By default nothing is logged.
+func WithLogger(logger *slog.Logger) Opt {
+	return func(s *Sanitizer) {
+		s.logger = logger
 	}
+}
 
-	// For non-string fields, always recurse to find tagged fields inside
-	// This allows sanitization of nested structs, slices, maps, etc.
-	if field.Kind() != reflect.String {
-		return s.sanitizeRecursive(field)
+// WithTextMarshalerSupport makes tagged values implementing both
+// [encoding.TextMarshaler] and [encoding.TextUnmarshaler], such as value
+// objects wrapping a string, sanitized as text: the value is marshaled, the
+// policy is applied and the result is unmarshaled back when it changed.
+// Untagged values are walked as usual.
+func WithTextMarshalerSupport() Opt {
+	return func(s *Sanitizer) {
+		s.textMarshalers = true
 	}
+}
 
-	return nil
+// WithNullStringSupport makes [sql.NullString] and sql.Null[string] values
+// sanitized as strings, e.g. a sql.NullString field tagged `sanitize:"ugc"`
+// gets the ugc policy applied to its String field. Null values, whose Valid
+// field is false, are left untouched.
+func WithNullStringSupport() Opt {
+	return func(s *Sanitizer) {
+		s.nullStrings = true
+	}
 }
 
-// applySanitizationPolicy applies the specified policy to a string field
-func (s *Sanitizer) applySanitizationPolicy(field reflect.Value, policyName string) error {
-	policy, err := s.getPolicy(policyName)
-	if err != nil {
-		return err
+// WithMaxFieldSize limits the size in bytes of fields passed to policies
+// during struct sanitization, protecting against slow sanitization of huge
+// inputs. Larger fields are rejected with a SizeError, unless truncation is
+// selected with WithOversizeMode. Zero disables the limit.
+func WithMaxFieldSize(bytes int) Opt {
+	return func(s *Sanitizer) {
+		s.maxFieldSize = max(bytes, 0)
 	}
+}
 
-	sanitized := policy.Sanitize(field.String())
-	field.SetString(sanitized)
-	return nil
+// WithOversizeMode sets how fields exceeding the limit set with
+// WithMaxFieldSize are handled. The default is ErrorOnOversize.
+func WithOversizeMode(mode OversizeMode) Opt {
+	return func(s *Sanitizer) {
+		s.oversize = mode
+	}
 }
 
-// getPolicy retrieves a policy by name with proper locking
-func (s *Sanitizer) getPolicy(name string) (Policy, error) {
-	s.mu.RLock()
-	policy, ok := s.policies[name]
-	s.mu.RUnlock()
+// WithKeyPolicy sets a policy sanitizing the keys of free-form maps, i.e.
+// maps with string keys and interface values such as map[string]any,
+// including maps nested in their values. Maps whose tag selects a key policy
+// with the key option keep it. Sanitized keys replace the original ones. When
+// keys collide after sanitization, an entry whose key was already clean wins,
+// otherwise the entry with the smallest original key is kept.
+func WithKeyPolicy(name string) Opt {
+	return func(s *Sanitizer) {
+		s.keyPolicy = name
+	}
+}
 
-	if !ok {
-		return nil, fmt.Errorf("policy %q: %w", name, ErrPolicyNotFound)
+// WithPanicRecovery makes struct sanitization recover from panics of policies,
+// e.g. buggy third-party policies, returning a FieldError wrapping
+// ErrPolicyPanic that names the field and the policy instead.
+func WithPanicRecovery() Opt {
+	return func(s *Sanitizer) {
+		s.recoverPanics = true
 	}
+}
 
-	return policy, nil
+// WithObserver sets an observer notified for every policy applied to a value
+// during struct sanitization. Policies chained in a tag are reported
+// separately.
+func WithObserver(o Observer) Opt {
+	return func(s *Sanitizer) {
+		s.observer = o
+	}
 }
 
-// sanitizePointer handles pointer sanitization
-func (s *Sanitizer) sanitizePointer(rv reflect.Value) error {
-	if rv.IsNil() {
-		return nil
+// WithParallelism sets the number of goroutines used to sanitize elements of
+// slices and arrays holding at least 256 elements. The default of 1 keeps
+// processing sequential. Elements must not share memory, and field hooks may
+// be invoked concurrently when parallelism is enabled.
+func WithParallelism(n int) Opt {
+	return func(s *Sanitizer) {
+		s.parallelism = max(n, 1)
 	}
-	return s.sanitizeRecursive(rv.Elem())
 }
 
-// sanitizeSliceOrArray handles slice and array sanitization
-func (s *Sanitizer) sanitizeSliceOrArray(rv reflect.Value) error {
-	for i := 0; i < rv.Len(); i++ {
-		if err := s.sanitizeRecursive(rv.Index(i)); err != nil {
-			return err
-		}
+// Add allows adding custom sanitizers to this instance.
+// Reserved names, such as the skip marker, cannot be used as policy names.
+func (s *Sanitizer) Add(name string, policy *bluemonday.Policy) {
+	s.mustNotBeReserved(name)
+
+	s.add(name, policy)
+}
+
+// AddPolicy adds a sanitization policy to this instance. Unlike Add, it
+// returns ErrInvalidPolicyName for reserved or empty names instead of
+// panicking, which suits names coming from configuration.
+func (s *Sanitizer) AddPolicy(name string, policy Policy) error {
+	if name == "" || s.isReserved(name) {
+		return fmt.Errorf("policy %q: %w", name, ErrInvalidPolicyName)
 	}
+
+	s.add(name, policy)
 	return nil
 }
 
-// sanitizeMap handles map sanitization with improved logic
-func (s *Sanitizer) sanitizeMap(rv reflect.Value) error {
-	for _, key := range rv.MapKeys() {
-		val := rv.MapIndex(key)
-		if !val.CanInterface() {
-			continue
-		}
+// isReserved reports whether the name is reserved.
+func (s *Sanitizer) isReserved(name string) bool {
+	_, ok := s.reserved[name]
+	return ok
+}
 
-		newVal := reflect.New(val.Type()).Elem()
-		newVal.Set(val)
-		if err := s.sanitizeRecursive(newVal); err != nil {
-			return err
-		}
+// policyName normalizes the name of a policy or alias.
+func (s *Sanitizer) policyName(name string) string {
+	if s.foldCase {
+		return strings.ToLower(name)
+	}
+	return name
+}
 
-		rv.SetMapIndex(key, newVal)
+func (s *Sanitizer) mustNotBeReserved(name string) {
+	if s.isReserved(name) {
+		panic(fmt.Sprintf("policy name %q is reserved for skipping sanitization", name))
 	}
-	return nil
 }
 
-// sanitizeInterface handles interface sanitization
-func (s *Sanitizer) sanitizeInterface(rv reflect.Value) error {
-	if rv.IsNil() {
-		return nil
+func (s *Sanitizer) add(name string, policy Policy) {
+	name = s.policyName(name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policies[name] = policy
+	delete(s.builders, name)
+	clear(s.derived)
+}
+
+// Alias makes the alias name resolve to the target policy. Aliases may point
+// to other aliases, registered policies take precedence over aliases.
+// Reserved names, such as the skip marker, cannot be used as aliases.
+func (s *Sanitizer) Alias(alias, target string) {
+	s.mustNotBeReserved(alias)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aliases[s.policyName(alias)] = s.policyName(target)
+}
+
+// Remove a sanitizer policy or alias by name. It reports whether a policy
+// or alias with the name was registered.
+func (s *Sanitizer) Remove(name string) bool {
+	name = s.policyName(name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, isPolicy := s.policies[name]
+	_, isAlias := s.aliases[name]
+	delete(s.policies, name)
+	delete(s.aliases, name)
+	clear(s.derived)
+	return isPolicy || isAlias
+}
+
+// Merge imports the policies and aliases registered in other. Names already
+// registered in s are kept unless overwrite is set. Names reserved in s are
+// skipped. Policies registered with a builder keep supporting inline tag
+// options.
+func (s *Sanitizer) Merge(other *Sanitizer, overwrite bool) {
+	if s.registry == other.registry {
+		return
+	}
+
+	// Lock in a fixed order, so concurrent merges in both directions cannot
+	// deadlock.
+	if reflect.ValueOf(s.registry).Pointer() < reflect.ValueOf(other.registry).Pointer() {
+		s.mu.Lock()
+		other.mu.RLock()
+	} else {
+		other.mu.RLock()
+		s.mu.Lock()
+	}
+	defer s.mu.Unlock()
+	defer other.mu.RUnlock()
+
+	for src, policy := range other.policies {
+		name := s.policyName(src)
+		if s.isReserved(name) {
+			continue
+		}
+		if _, ok := s.policies[name]; ok && !overwrite {
+			continue
+		}
+		s.policies[name] = policy
+		if b, ok := other.builders[src]; ok {
+			s.builders[name] = b
+		} else {
+			delete(s.builders, name)
+		}
+	}
+	for alias, target := range other.aliases {
+		alias = s.policyName(alias)
+		if s.isReserved(alias) {
+			continue
+		}
+		if _, ok := s.aliases[alias]; ok && !overwrite {
+			continue
+		}
+		s.aliases[alias] = s.policyName(target)
+	}
+	clear(s.derived)
+}
+
+// TagKey returns the struct tag key holding sanitization policies.
+func (s *Sanitizer) TagKey() string {
+	return s.tagKey
+}
+
+// Len returns the number of registered policies, aliases not included.
+func (s *Sanitizer) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.policies)
+}
+
+// Snapshot returns a copy of the registered policies, which may be passed to
+// Restore later. Aliases are not included.
+func (s *Sanitizer) Snapshot() map[string]Policy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return maps.Clone(s.policies)
+}
+
+// Restore replaces the registered policies with a copy of the given ones,
+// typically obtained from Snapshot. Policies restored unchanged keep
+// supporting inline tag options.
+func (s *Sanitizer) Restore(policies map[string]Policy) {
+	restored := make(map[string]Policy, len(policies))
+	for name, policy := range policies {
+		restored[s.policyName(name)] = policy
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policies = restored
+	for name, b := range s.builders {
+		if p, ok := restored[name].(*bluemonday.Policy); !ok || p != b.policy {
+			delete(s.builders, name)
+		}
+	}
+	clear(s.derived)
+}
+
+// Reset removes all registered policies and aliases, e.g. for test teardown
+// or before reconfiguring the Sanitizer from scratch. Afterwards every
+// SanitizeString call returns ErrPolicyNotFound until policies are added
+// again. Policies restored from a snapshot taken before Reset no longer
+// support inline tag options. Settings and registered types are kept.
+func (s *Sanitizer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.policies)
+	clear(s.aliases)
+	clear(s.builders)
+	clear(s.derived)
+}
+
+// SanitizeString applies sanitization based on the given policy name.
+func (s *Sanitizer) SanitizeString(policy string, input string) (string, error) {
+	p, err := s.getPolicy(policy)
+	if err != nil {
+		return "", err
+	}
+
+	return s.sanitize(nil, p, input)
+}
+
+// SanitizeStringMulti applies the named policies to input in order, like a
+// chain of policies in a struct tag. All policies are looked up before any is
+// applied, an unknown name yields a PolicyNotFoundError naming it.
+func (s *Sanitizer) SanitizeStringMulti(policies []string, input string) (string, error) {
+	chain := make([]Policy, len(policies))
+	for i, name := range policies {
+		p, err := s.getPolicy(name)
+		if err != nil {
+			return "", err
+		}
+		chain[i] = p
+	}
+
+	for i, p := range chain {
+		var err error
+		if input, err = s.sanitize(nil, p, input); err != nil {
+			return "", fmt.Errorf("policy %q: %w", policies[i], err)
+		}
+	}
+	return input, nil
+}
+
+// SanitizeStrings applies the policy to each input, returning the results in
+// order. The policy is looked up once, before any input is processed. A
+// rejected input stops sanitization with an error naming its index.
+func (s *Sanitizer) SanitizeStrings(policy string, inputs ...string) ([]string, error) {
+	p, err := s.getPolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]string, len(inputs))
+	for i, input := range inputs {
+		if out[i], err = s.sanitize(nil, p, input); err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+	return out, nil
+}
+
+// bytesPolicy is implemented by policies able to sanitize byte slices
+// directly, such as [bluemonday.Policy].
+type bytesPolicy interface {
+	SanitizeBytes(b []byte) []byte
+}
+
+// SanitizeBytes sanitizes b with the given policy. Policies backed by
+// bluemonday sanitize the bytes directly, other policies convert them to a
+// string once.
+func (s *Sanitizer) SanitizeBytes(policy string, b []byte) ([]byte, error) {
+	p, err := s.getPolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	if bp, ok := p.(bytesPolicy); ok {
+		return bp.SanitizeBytes(b), nil
+	}
+	out, err := s.sanitize(nil, p, string(b))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+// sanitize applies the policy to input, passing ctx to context-aware
+// policies when set, reporting rejections of checked policies and lending
+// pooled buffers to buffered policies.
+func (s *Sanitizer) sanitize(ctx context.Context, p Policy, input string) (string, error) {
+	if cp, ok := p.(ContextPolicy); ok && ctx != nil {
+		return cp.SanitizeContext(ctx, input), nil
+	}
+	if cp, ok := p.(CheckedPolicy); ok {
+		return cp.SanitizeChecked(input)
+	}
+	if bp, ok := p.(BufferedPolicy); ok {
+		buf := s.getBuffer()
+		defer s.putBuffer(buf)
+		bp.SanitizeTo(buf, input)
+		return buf.String(), nil
+	}
+	return p.Sanitize(input), nil
+}
+
+// streamPolicy is implemented by policies able to sanitize a stream without
+// buffering it, such as [bluemonday.Policy].
+type streamPolicy interface {
+	SanitizeReaderToWriter(r io.Reader, w io.Writer) error
+}
+
+// SanitizeReader sanitizes the HTML read from r with the given policy and
+// writes the result to w. Policies backed by bluemonday stream the input,
+// other policies buffer it whole before sanitizing.
+func (s *Sanitizer) SanitizeReader(policy string, r io.Reader, w io.Writer) error {
+	p, err := s.getPolicy(policy)
+	if err != nil {
+		return err
+	}
+
+	if sp, ok := p.(streamPolicy); ok {
+		return sp.SanitizeReaderToWriter(r, w)
+	}
+
+	buf := s.getBuffer()
+	defer s.putBuffer(buf)
+	if _, err := buf.ReadFrom(r); err != nil {
+		return err
+	}
+	out, err := s.sanitize(nil, p, buf.String())
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
+
+// getBuffer returns an empty buffer from the pool, or a new one.
+func (s *Sanitizer) getBuffer() *bytes.Buffer {
+	if s.bufferPool != nil {
+		if buf, ok := s.bufferPool.Get().(*bytes.Buffer); ok {
+			buf.Reset()
+			return buf
+		}
+	}
+	return new(bytes.Buffer)
+}
+
+// putBuffer returns the buffer to the pool, if any.
+func (s *Sanitizer) putBuffer(buf *bytes.Buffer) {
+	if s.bufferPool != nil {
+		s.bufferPool.Put(buf)
+	}
+}
+
+// SanitizeStruct applies sanitization based on struct tags.
+// Only exported fields are sanitized, including exported fields of embedded
+// unexported structs. Unexported fields cannot be set via reflection and are
+// left untouched.
+// Zero values are walked as well: empty strings are passed to policies, so
+// policies may fill in or reject missing values, and the tags of empty fields
+// are checked, so an unknown policy yields an error even on an empty field.
+// Walking a zero struct therefore costs as much as walking a populated one.
+//
+// A policy tagged on a pointer, slice, array, map or interface applies to
+// every string inside it, but not to fields of nested structs, which follow
+// their own tags. The deep option, e.g. `sanitize:"strict;deep"`, extends the
+// policy to untagged fields of nested structs at any depth, including
+// structs held in collections. Tags closer to a value take precedence: a
+// tagged nested field uses its own policy, a field tagged with the skip
+// marker is skipped and self-sanitizing types still sanitize themselves after
+// the inherited policy. A field with an empty tag uses the default policy, or
+// the inherited policy when no default policy is set. The deep option cannot
+// be combined with the key and val options of maps.
+//
+// The required option, e.g. `sanitize:"strict;required"`, stops the walk with
+// an error wrapping ErrEmptied when sanitization empties a non-empty value,
+// which usually means the input consisted solely of malicious markup. The
+// field is left unchanged.
+//
+// The mode option selects between stripping markup, the default
+// `sanitize:"ugc;mode=strip"`, and escaping it, `sanitize:"ugc;mode=escape"`,
+// for fields displaying markup literally. When escaping, the first bluemonday
+// policy in the chain is replaced with Escape and later ones are dropped, so
+// the value is escaped once, while other policies, such as a custom trim,
+// are applied in order as usual. Named policies must still be
+// registered. Escaping cannot be combined with allow options.
+func (s *Sanitizer) SanitizeStruct(v any) error {
+	return s.walk(v, &walker{Sanitizer: s})
+}
+
+// SanitizeStructValue applies sanitization like SanitizeStruct, taking a
+// typed pointer, so passing a value instead of a pointer fails to compile
+// rather than at runtime. A nil s uses the default Sanitizer. A nil v yields
+// the same error as SanitizeStruct.
+func SanitizeStructValue[T any](s *Sanitizer, v *T) error {
+	if s == nil {
+		s = Default()
+	}
+	return s.SanitizeStruct(v)
+}
+
+// SanitizeEach sanitizes every struct in a slice of structs or of pointers
+// to structs, or in a pointer to such a slice or array, as SanitizeStruct
+// does. Nil elements are skipped. Sanitization stops at the first error,
+// whose path starts with the element index, e.g. "[2].Name".
+func (s *Sanitizer) SanitizeEach(slice any) error {
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() && (rv.Elem().Kind() == reflect.Array || rv.Elem().Kind() == reflect.Slice) {
+		rv = rv.Elem()
+	}
+	// Arrays passed by value are copies, changes to them would be lost.
+	if rv.Kind() != reflect.Slice && (rv.Kind() != reflect.Array || !rv.CanAddr()) {
+		return fmt.Errorf("expected slice of structs or pointers to structs, got %T", slice)
+	}
+
+	elem := rv.Type().Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("expected slice of structs or pointers to structs, got %T", slice)
+	}
+
+	w := &walker{Sanitizer: s}
+	w.acquire()
+	defer w.release()
+	return w.sanitizeSliceOrArray(rv, "")
+}
+
+// SanitizeValue sanitizes the value rv refers to, following the rules of
+// SanitizeStruct, for callers that already hold a reflect.Value such as
+// custom walkers or other reflection-based libraries. The value must be
+// settable, e.g. obtained through Elem of a pointer or a field of an
+// addressable struct, or be a non-nil pointer. Values from reflect.ValueOf
+// of a non-pointer, or from unexported fields, are rejected since changes
+// to them would be lost.
+func (s *Sanitizer) SanitizeValue(rv reflect.Value) error {
+	if !rv.IsValid() {
+		return errors.New("expected addressable value, got invalid value")
+	}
+	if !rv.CanSet() && (rv.Kind() != reflect.Ptr || rv.IsNil()) {
+		return fmt.Errorf("expected addressable value, got %s", rv.Type())
+	}
+
+	w := &walker{Sanitizer: s}
+	w.acquire()
+	defer w.release()
+	return w.sanitizeRecursive(rv, "")
+}
+
+// SanitizeStructWith applies sanitization like SanitizeStruct, replacing
+// policy names found in tags according to overrides for this call only,
+// e.g. {"ugc": "strict"} sanitizes fields tagged "ugc" with the strict
+// policy. Override targets must be registered policies or aliases.
+func (s *Sanitizer) SanitizeStructWith(v any, overrides map[string]string) error {
+	return s.walk(v, &walker{Sanitizer: s, overrides: overrides})
+}
+
+// SanitizeStructContext applies sanitization like SanitizeStruct, passing ctx
+// to policies implementing ContextPolicy.
+func (s *Sanitizer) SanitizeStructContext(ctx context.Context, v any) error {
+	return s.walk(v, &walker{Sanitizer: s, ctx: ctx})
+}
+
+// SanitizeStructReport applies sanitization like SanitizeStruct and reports
+// whether any field value was modified. On error, changed reflects the
+// fields sanitized before the walk stopped.
+func (s *Sanitizer) SanitizeStructReport(v any) (changed bool, err error) {
+	w := &walker{Sanitizer: s}
+	err = s.walk(v, w)
+	return w.changed, err
+}
+
+// Report summarizes a sanitization pass.
+type Report struct {
+	// Changed reports whether any value was modified.
+	Changed bool
+	// EmptiedFields lists the paths of values that were non-empty before
+	// sanitization and are empty after it, e.g. input consisting solely of a
+	// script. Such input is often malicious as a whole.
+	EmptiedFields []string
+}
+
+// SanitizeStructDetailed applies sanitization like SanitizeStruct and
+// returns a report of the pass. On error, the report reflects the fields
+// sanitized before the walk stopped.
+func (s *Sanitizer) SanitizeStructDetailed(v any) (Report, error) {
+	w := &walker{Sanitizer: s}
+	err := s.walk(v, w)
+	return Report{Changed: w.changed, EmptiedFields: w.emptied}, err
+}
+
+// Change describes a modification sanitization made, or would make, to a
+// string value.
+type Change struct {
+	// Path is the location of the value, e.g. "Comments[0].Author".
+	Path string
+	// Policy is the name of the applied policy.
+	Policy string
+	Before string
+	After  string
+}
+
+// DryRun walks v like SanitizeStruct but leaves it untouched, returning the
+// changes sanitization would make in traversal order. Field hooks and
+// handlers of types registered with RegisterType are not invoked.
+func (s *Sanitizer) DryRun(v any) ([]Change, error) {
+	w := &walker{Sanitizer: s, dryRun: true}
+	err := s.walk(v, w)
+	return w.changes, err
+}
+
+// walk validates the input and sanitizes it using the given walker.
+func (s *Sanitizer) walk(v any, w *walker) error {
+	if v == nil {
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("expected pointer to struct, got %T", v)
+	}
+
+	w.acquire()
+	defer w.release()
+	return w.sanitizeRecursive(rv.Elem(), "")
+}
+
+// walker holds the state of a single sanitization pass.
+type walker struct {
+	*Sanitizer
+	changed  bool
+	parallel bool
+	// dryRun records changes instead of applying them.
+	dryRun bool
+	// audit records changes while applying them.
+	audit   bool
+	changes []Change
+	// emptied lists paths of values emptied by sanitization.
+	emptied []string
+	// overrides replaces policy names for the duration of the walk.
+	overrides map[string]string
+	// ctx is passed to context-aware policies, it may be nil.
+	ctx context.Context
+	// state holds the scratch memory of the walk, it is shared with forked
+	// walkers.
+	state *walkState
+	// path is the location of the visited value, e.g. "Comments[0].Author".
+	// Segments are pushed and popped while descending, the path is only
+	// turned into a string when reported.
+	path []byte
+	// depth is the number of structs being walked.
+	depth int
+	// deep is the tag of the closest field tagged with the deep option,
+	// inherited by untagged fields of nested structs.
+	deep string
+	// only limits the walk to the paths passed to SanitizeFields, it is nil
+	// for full walks.
+	only *pathSet
+}
+
+// visit identifies a pointer target sanitized with a policy.
+type visit struct {
+	ptr    uintptr
+	typ    reflect.Type
+	policy string
+}
+
+// walkState holds the scratch memory of a walk, pooled so repeated calls
+// reuse its allocations.
+type walkState struct {
+	path []byte
+	mu   sync.Mutex
+	// seen holds the pointers visited with WithIdempotencyCache.
+	seen map[visit]struct{}
+}
+
+// Pooled states growing past these sizes are dropped instead of reused, so
+// a single large value does not pin its memory.
+const (
+	maxPooledPath = 1 << 10
+	maxPooledSeen = 1 << 10
+)
+
+var walkStates = sync.Pool{New: func() any { return new(walkState) }}
+
+// acquire takes a walk state from the pool, it must be released once the
+// walk finishes.
+func (w *walker) acquire() {
+	w.state = walkStates.Get().(*walkState)
+	w.path = w.state.path[:0]
+}
+
+// release resets the walk state and returns it to the pool.
+func (w *walker) release() {
+	st := w.state
+	w.state = nil
+	st.path = nil
+	if cap(w.path) <= maxPooledPath {
+		st.path = w.path[:0]
+	}
+	w.path = nil
+	if len(st.seen) > maxPooledSeen {
+		st.seen = nil
+	} else {
+		clear(st.seen)
+	}
+	walkStates.Put(st)
+}
+
+// visited records the pointer target as visited and reports whether it was
+// visited before.
+func (st *walkState) visited(key visit) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if _, ok := st.seen[key]; ok {
+		return true
+	}
+	if st.seen == nil {
+		st.seen = make(map[visit]struct{})
+	}
+	st.seen[key] = struct{}{}
+	return false
+}
+
+// fork returns a walker for processing part of the value concurrently.
+func (w *walker) fork() *walker {
+	return &walker{
+		Sanitizer: w.Sanitizer,
+		parallel:  true,
+		dryRun:    w.dryRun,
+		audit:     w.audit,
+		overrides: w.overrides,
+		ctx:       w.ctx,
+		state:     w.state,
+		path:      slices.Clone(w.path),
+		depth:     w.depth,
+		deep:      w.deep,
+		only:      w.only,
+	}
+}
+
+// join merges the state of a forked walker back.
+func (w *walker) join(child *walker) {
+	w.changed = w.changed || child.changed
+	w.changes = append(w.changes, child.changes...)
+	w.emptied = append(w.emptied, child.emptied...)
+}
+
+// tagPolicy returns the named policy, deriving and caching a new policy from
+// the registered builder when elements to allow are given.
+func (s *Sanitizer) tagPolicy(name string, allow []string) (Policy, error) {
+	if len(allow) == 0 {
+		return s.getPolicy(name)
+	}
+
+	name = s.scoped(name)
+	s.mu.RLock()
+	// Aliases share the builder and derived policies of their target.
+	target, err := s.resolve(name)
+	if err != nil {
+		s.mu.RUnlock()
+		return nil, err
+	}
+	key := target + ";allow=" + strings.Join(allow, ",allow=")
+	policy, ok := s.derived[key]
+	b, derivable := s.builders[target]
+	s.mu.RUnlock()
+	if ok {
+		return policy, nil
+	}
+	if !derivable {
+		return nil, fmt.Errorf("policy %q: %w", name, ErrNotDerivable)
+	}
+
+	derived := b.build().AllowElements(allow...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.derived[key] = derived
+	return derived, nil
+}
+
+// getPolicy retrieves a policy by name, constructing lazy policies outside
+// of the lock.
+func (s *Sanitizer) getPolicy(name string) (Policy, error) {
+	policy, err := s.lookupPolicy(s.scoped(name))
+	if lazy, ok := policy.(*lazyPolicy); ok {
+		return lazy.get(), nil
+	}
+	return policy, err
+}
+
+// lookupPolicy retrieves a registered policy by name with proper locking
+func (s *Sanitizer) lookupPolicy(name string) (Policy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resolved, err := s.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.policies[resolved], nil
+}
+
+// resolve follows aliases from name to the name of a registered policy. The
+// caller must hold s.mu.
+func (s *Sanitizer) resolve(name string) (string, error) {
+	resolved := s.policyName(name)
+	for hops := 0; ; hops++ {
+		if _, ok := s.policies[resolved]; ok {
+			return resolved, nil
+		}
+
+		target, ok := s.aliases[resolved]
+		if !ok {
+			return "", &PolicyNotFoundError{Name: name}
+		}
+		if hops >= len(s.aliases) {
+			return "", fmt.Errorf("policy %q: %w", name, ErrAliasCycle)
+		}
+
+		resolved = target
+	}
+}
+
+// sanitizeRecursive walks rv and sanitizes string values it reaches. The
+// policy is inherited from the closest tagged field, it is carried through
+// pointers, slices, arrays, maps and interfaces but not into nested structs,
+// whose fields are governed by their own tags.
+func (w *walker) sanitizeRecursive(rv reflect.Value, policy string) error {
+	if !rv.IsValid() {
+		return nil
+	}
+	if w.only != nil && !w.only.visit(w.path) {
+		return nil
+	}
+	if w.skipFunc != nil && w.skipFunc(rv.Type()) {
+		w.debug("skipping value", "reason", "skip func", "type", rv.Type())
+		return nil
+	}
+	if handler := w.typeHandler(rv.Type()); handler != nil {
+		return w.sanitizeWith(handler, rv, policy)
+	}
+	if rv.Type() == rawMessageType {
+		return w.sanitizeRawJSON(rv, policy)
+	}
+	if rv.Type() == numberType {
+		return nil
+	}
+	if w.nullStrings && isNullString(rv.Type()) {
+		return w.sanitizeNullString(rv, policy)
+	}
+	if w.textMarshalers && policy != "" && isText(rv) {
+		return w.sanitizeText(rv, policy)
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return w.sanitizeString(rv, policy)
+	case reflect.Struct:
+		return w.sanitizeStruct(rv)
+	case reflect.Ptr:
+		return w.sanitizePointer(rv, policy)
+	case reflect.Slice, reflect.Array:
+		return w.sanitizeSliceOrArray(rv, policy)
+	case reflect.Map:
+		return w.sanitizeMap(rv, policy)
+	case reflect.Interface:
+		return w.sanitizeInterface(rv, policy)
+	}
+
+	return nil
+}
+
+// sanitizeStruct processes struct fields and applies sanitization based on tags
+func (w *walker) sanitizeStruct(rv reflect.Value) error {
+	if w.logger != nil {
+		w.depth++
+		defer func() { w.depth-- }()
+		w.debug("walking struct", "type", rv.Type(), "depth", w.depth)
+	}
+	if w.only != nil {
+		w.visitFields(rv.Type())
+	}
+
+	for _, fp := range w.structPlan(rv.Type()) {
+		n := w.pushField(fp.name)
+		err := w.sanitizePlannedField(rv, fp)
+		w.pop(n)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sanitizePlannedField sanitizes a field of the struct rv unless it is
+// excluded from the walk.
+func (w *walker) sanitizePlannedField(rv reflect.Value, fp fieldPlan) error {
+	if w.only != nil && !w.only.includes(w.path) {
+		return nil
+	}
+	// Tagged unexported fields are only planned to be logged.
+	if !fp.sf.IsExported() && !isEmbeddedStruct(fp.sf) {
+		w.debug("skipping field", "reason", "tagged but unexported", "tag", fp.tag)
+		return nil
+	}
+	field := rv.Field(fp.index)
+	// Embedded unexported structs are read-only themselves, but their
+	// exported fields are settable.
+	if !field.CanSet() && !fp.sf.Anonymous {
+		w.debug("skipping field", "reason", "not settable")
+		return nil
+	}
+	return w.sanitizeField(rv, field, fp)
+}
+
+// sanitizeField handles individual field sanitization
+func (w *walker) sanitizeField(parent, field reflect.Value, fp fieldPlan) error {
+	tag := fp.tag
+	if w.resolver != nil {
+		if policy, ok := w.resolver(fp.sf, parent); ok {
+			tag = policy
+		}
+	}
+
+	if w.isReserved(tag) {
+		w.debug("skipping field", "reason", "reserved tag", "tag", tag)
+		return nil
+	}
+	if tag == "" && !fp.tagged {
+		tag = w.deep
+	}
+	explicit := tag != "" || fp.tagged
+	if tag == "" && fp.tagged {
+		tag = w.defaultPolicy
+		if tag == "" {
+			tag = w.deep
+		}
+	}
+	if w.requireTags && !explicit && field.Kind() == reflect.String && fp.sf.IsExported() {
+		return fmt.Errorf("%s: %w", w.path, ErrMissingTag)
+	}
+	if w.shallow && !explicit && field.Kind() != reflect.String {
+		w.debug("skipping field", "reason", "untagged in shallow mode")
+		return nil
+	}
+
+	if tag != "" && tag != w.deep {
+		spec, err := w.parseTag(tag)
+		if err != nil {
+			return fmt.Errorf("%s: %w", w.path, err)
+		}
+		if spec.deep {
+			deep := w.deep
+			w.deep = tag
+			defer func() { w.deep = deep }()
+		}
+	}
+
+	// Always recurse to find tagged fields inside non-string fields.
+	// This allows sanitization of nested structs, slices, maps, etc.
+	return w.sanitizeRecursive(field, tag)
+}
+
+// sanitizeString applies the inherited policy, or the default policy when
+// there is none, to a string value
+func (w *walker) sanitizeString(rv reflect.Value, policy string) error {
+	if w.isReserved(policy) {
+		return nil
+	}
+	if policy == "" {
+		policy = w.defaultPolicy
+	}
+	if policy != "" {
+		if err := w.applySanitizationPolicy(rv, policy); err != nil {
+			return err
+		}
+	}
+
+	return w.sanitizeSelf(rv)
+}
+
+var sanitizableType = reflect.TypeFor[Sanitizable]()
+
+var (
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// isText reports whether rv is a settable value other than a string that
+// marshals to and from text. Values reached through unexported embedded
+// structs are addressable but cannot be converted to interfaces.
+func isText(rv reflect.Value) bool {
+	if rv.Kind() == reflect.String || !rv.CanSet() {
+		return false
+	}
+	ptr := reflect.PointerTo(rv.Type())
+	return ptr.Implements(textMarshalerType) && ptr.Implements(textUnmarshalerType)
+}
+
+// sanitizeText applies the policy to the text form of a value, unmarshaling
+// the sanitized text back into it when it changed.
+func (w *walker) sanitizeText(rv reflect.Value, policy string) error {
+	text, err := rv.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return fmt.Errorf("%s: %w", w.path, err)
+	}
+
+	str := reflect.New(reflect.TypeFor[string]()).Elem()
+	str.SetString(string(text))
+	if err := w.applySanitizationPolicy(str, policy); err != nil {
+		return err
+	}
+	if w.dryRun || str.String() == string(text) {
+		return nil
+	}
+
+	if err := rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str.String())); err != nil {
+		return fmt.Errorf("%s: %w", w.path, err)
+	}
+	return nil
+}
+
+// sanitizeSelf calls the Sanitize method of named string types implementing
+// Sanitizable, after any tag policy was applied.
+func (w *walker) sanitizeSelf(rv reflect.Value) error {
+	// Only named types declare methods, plain strings take the fast path.
+	if rv.Type().PkgPath() == "" || w.dryRun || !rv.CanSet() {
+		return nil
+	}
+	if !reflect.PointerTo(rv.Type()).Implements(sanitizableType) {
+		return nil
+	}
+
+	before := rv.String()
+	if err := rv.Addr().Interface().(Sanitizable).Sanitize(w.Sanitizer); err != nil {
+		return fmt.Errorf("%s: %w", w.path, err)
+	}
+	if rv.String() != before {
+		w.changed = true
+	}
+	return nil
+}
+
+// applySanitizationPolicy applies the policy described by the tag to a string field
+func (w *walker) applySanitizationPolicy(field reflect.Value, tag string) error {
+	spec, err := w.parseTag(tag)
+	if err != nil {
+		return fmt.Errorf("%s: %w", w.path, err)
+	}
+	if spec.isKeyVal() {
+		return fmt.Errorf("%s: key and val options only apply to maps: %w", w.path, ErrInvalidTag)
+	}
+
+	if len(w.overrides) > 0 {
+		names := make([]string, len(spec.names))
+		for i, name := range spec.names {
+			if target, ok := w.overrides[name]; ok {
+				name = target
+			}
+			names[i] = name
+		}
+		spec.names = names
+	}
+
+	before := field.String()
+	sanitized := before
+	if w.maxFieldSize > 0 && len(before) > w.maxFieldSize {
+		if w.oversize != TruncateOversize {
+			return &SizeError{Path: string(w.path), Size: len(before), Limit: w.maxFieldSize}
+		}
+		sanitized = truncate(before, w.maxFieldSize)
+	}
+	escaped := false
+	for _, name := range spec.names {
+		policy, err := w.tagPolicy(name, spec.allow)
+		if err != nil {
+			var notFound *PolicyNotFoundError
+			if errors.As(err, &notFound) {
+				if w.unknownPolicy == SkipOnUnknown {
+					w.debug("skipping field", "reason", "unknown policy", "policy", name)
+					if w.fieldHook != nil && !w.dryRun {
+						w.fieldHook(string(w.path), spec.policy(), before, before)
+					}
+					return nil
+				}
+				notFound.Path = string(w.path)
+				return err
+			}
+			return fmt.Errorf("%s: %w", w.path, err)
+		}
+		if _, ok := policy.(*bluemonday.Policy); ok && spec.escapes() {
+			if escaped {
+				continue
+			}
+			policy, escaped = Escape, true
+		}
+
+		sanitized, err = w.applyPolicy(name, policy, sanitized)
+		if err != nil {
+			return &FieldError{Path: string(w.path), Policy: name, Err: err}
+		}
+	}
+	if spec.max > 0 && len(sanitized) > spec.max {
+		return &SizeError{Path: string(w.path), Size: len(sanitized), Limit: spec.max}
+	}
+	if spec.required && sanitized == "" && before != "" {
+		return fmt.Errorf("%s: policy %q: %w", w.path, spec.policy(), ErrEmptied)
+	}
+
+	if w.dryRun {
+		if sanitized != before {
+			w.changes = append(w.changes, Change{Path: string(w.path), Policy: spec.policy(), Before: before, After: sanitized})
+		}
+		return nil
+	}
+
+	field.SetString(sanitized)
+	if sanitized != before {
+		w.changed = true
+		if w.audit {
+			w.changes = append(w.changes, Change{Path: string(w.path), Policy: spec.policy(), Before: before, After: sanitized})
+		}
+		if sanitized == "" {
+			w.emptied = append(w.emptied, string(w.path))
+		}
+		if w.changeCh != nil {
+			select {
+			case w.changeCh <- Change{Path: string(w.path), Policy: spec.policy(), Before: before, After: sanitized}:
+			default:
+			}
+		}
+	}
+
+	if w.fieldHook != nil {
+		w.fieldHook(string(w.path), spec.policy(), before, sanitized)
+	}
+	return nil
+}
+
+// truncate cuts s to at most n bytes, without splitting a UTF-8 encoded
+// character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// debug logs a debug message about the visited value, along with its path,
+// when a logger enabled for the level is set.
+func (w *walker) debug(msg string, args ...any) {
+	if w.logger == nil {
+		return
+	}
+	ctx := w.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if w.logger.Enabled(ctx, slog.LevelDebug) {
+		w.logger.DebugContext(ctx, msg, append([]any{"path", string(w.path)}, args...)...)
+	}
+}
+
+// applyPolicy applies the named policy to input, recovering from panics when
+// requested.
+func (w *walker) applyPolicy(name string, policy Policy, input string) (out string, err error) {
+	if w.recoverPanics {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("%w: %v", ErrPolicyPanic, r)
+			}
+		}()
+	}
+	if w.observer != nil {
+		return w.observe(name, policy, input)
+	}
+	return w.sanitize(w.ctx, policy, input)
+}
+
+// observe applies the policy to input, reporting it to the observer.
+func (w *walker) observe(name string, policy Policy, input string) (string, error) {
+	start := time.Now()
+	out, err := w.sanitize(w.ctx, policy, input)
+	w.observer.Duration(name, time.Since(start))
+	if err == nil {
+		w.observer.FieldSanitized(name, max(len(input)-len(out), 0))
+	}
+	return out, err
+}
+
+// sanitizePointer handles pointer sanitization
+func (w *walker) sanitizePointer(rv reflect.Value, policy string) error {
+	if rv.IsNil() {
+		return nil
+	}
+	if w.idempotent && w.state.visited(visit{ptr: rv.Pointer(), typ: rv.Type(), policy: policy}) {
+		return nil
+	}
+	return w.sanitizeRecursive(rv.Elem(), policy)
+}
+
+// sanitizeSliceOrArray handles slice and array sanitization
+func (w *walker) sanitizeSliceOrArray(rv reflect.Value, policy string) error {
+	if w.parallelism > 1 && !w.parallel && rv.Len() >= parallelThreshold {
+		return w.sanitizeParallel(rv, policy)
+	}
+
+	for i := 0; i < rv.Len(); i++ {
+		n := w.pushIndex(i)
+		err := w.sanitizeRecursive(rv.Index(i), policy)
+		w.pop(n)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sanitizeParallel splits slice or array elements into contiguous chunks
+// processed by separate goroutines. The error of the lowest failing index
+// is returned, matching sequential processing.
+func (w *walker) sanitizeParallel(rv reflect.Value, policy string) error {
+	n := rv.Len()
+	workers := min(w.parallelism, n)
+	chunk := (n + workers - 1) / workers
+
+	children := make([]*walker, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		lo, hi := i*chunk, min((i+1)*chunk, n)
+		children[i] = w.fork()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			child := children[i]
+			for j := lo; j < hi; j++ {
+				n := child.pushIndex(j)
+				err := child.sanitizeRecursive(rv.Index(j), policy)
+				child.pop(n)
+				if err != nil {
+					errs[i] = err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, child := range children {
+		w.join(child)
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sanitizeMap handles map sanitization with improved logic
+func (w *walker) sanitizeMap(rv reflect.Value, policy string) error {
+	var keyPolicy string
+	if policy != "" {
+		spec, err := w.parseTag(policy)
+		if err != nil {
+			return fmt.Errorf("%s: %w", w.path, err)
+		}
+
+		if spec.isKeyVal() {
+			keyPolicy, policy = spec.key, spec.val
+		}
+
+		// A side without a policy is left untouched.
+		if spec.isKeyVal() && policy == "" {
+			if w.only != nil && !w.only.selects(w.path) {
+				return nil
+			}
+			return w.sanitizeMapKeys(rv, keyPolicy)
+		}
+	}
+
+	if err := w.sanitizeMapValues(rv, policy); err != nil {
+		return err
+	}
+
+	if keyPolicy == "" && w.keyPolicy != "" && rv.Type().Elem().Kind() == reflect.Interface {
+		keyPolicy = w.keyPolicy
+	}
+	if keyPolicy != "" && (w.only == nil || w.only.selects(w.path)) {
+		return w.sanitizeMapKeys(rv, keyPolicy)
+	}
+	return nil
+}
+
+// sanitizeMapValues sanitizes the values of a map with the given policy.
+func (w *walker) sanitizeMapValues(rv reflect.Value, policy string) error {
+	// Map values are not addressable, each one is sanitized in a scratch copy
+	// that is stored back only when it changed. The scratch values are reused
+	// across entries, as storing copies them into the map.
+	changed := w.changed
+	pointers := rv.Type().Elem().Kind() == reflect.Ptr
+	key := reflect.New(rv.Type().Key()).Elem()
+	val := reflect.New(rv.Type().Elem()).Elem()
+	iter := rv.MapRange()
+	for iter.Next() {
+		key.SetIterKey(iter)
+		val.SetIterValue(iter)
+		var target uintptr
+		if pointers {
+			target = val.Pointer()
+		}
+
+		w.changed = false
+		n := w.pushKey(key)
+		err := w.sanitizeRecursive(val, policy)
+		w.pop(n)
+		if err != nil {
+			w.changed = w.changed || changed
+			return err
+		}
+
+		if w.changed {
+			// Changes behind a pointer land in its shared target, the entry
+			// is only stored back when the pointer itself was replaced,
+			// e.g. by a type handler.
+			if !pointers || val.Pointer() != target {
+				rv.SetMapIndex(key, val)
+			}
+			changed = true
+		}
+	}
+	w.changed = changed
+	return nil
+}
+
+// sharesContents reports whether copies of values of the kind share the
+// values they hold, so that sanitizing a copy modifies the original.
+func sharesContents(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// sanitizeMapKeys sanitizes string map keys. Keys are immutable, so entries
+// with modified keys are reinserted under the sanitized key. When keys
+// collide after sanitization, an entry whose key was already clean wins,
+// otherwise the entry with the smallest original key is kept.
+func (w *walker) sanitizeMapKeys(rv reflect.Value, policy string) error {
+	if rv.Type().Key().Kind() != reflect.String {
+		return nil
+	}
+
+	type entry struct {
+		key, clean, val reflect.Value
+	}
+
+	// Keys are sanitized in a reused scratch value, only renamed entries are
+	// collected, as the map cannot be modified while iterating over it.
+	var renamed []entry
+	key := reflect.New(rv.Type().Key()).Elem()
+	clean := reflect.New(rv.Type().Key()).Elem()
+	iter := rv.MapRange()
+	for iter.Next() {
+		key.SetIterKey(iter)
+		clean.Set(key)
+		n := w.pushKey(key)
+		err := w.applySanitizationPolicy(clean, policy)
+		w.pop(n)
+		if err != nil {
+			return err
+		}
+
+		if clean.String() != key.String() {
+			e := entry{
+				key:   reflect.New(key.Type()).Elem(),
+				clean: reflect.New(key.Type()).Elem(),
+				val:   iter.Value(),
+			}
+			e.key.Set(key)
+			e.clean.Set(clean)
+			renamed = append(renamed, e)
+		}
+	}
+
+	sort.Slice(renamed, func(i, j int) bool {
+		return renamed[i].key.String() < renamed[j].key.String()
+	})
+	for _, e := range renamed {
+		rv.SetMapIndex(e.key, reflect.Value{})
+	}
+	for _, e := range renamed {
+		if rv.MapIndex(e.clean).IsValid() {
+			continue
+		}
+		rv.SetMapIndex(e.clean, e.val)
+	}
+	return nil
+}
+
+// sanitizeInterface handles interface sanitization
+func (w *walker) sanitizeInterface(rv reflect.Value, policy string) error {
+	if rv.IsNil() {
+		return nil
+	}
+
+	elem := reflect.ValueOf(rv.Interface())
+	if sharesContents(elem.Kind()) {
+		return w.sanitizeRecursive(elem, policy)
+	}
+
+	// Strings, structs and arrays held by interfaces are immutable, sanitize
+	// an addressable copy and store it back into the interface.
+	if !rv.CanSet() {
+		return nil
+	}
+
+	cp := reflect.New(elem.Type()).Elem()
+	cp.Set(elem)
+
+	changed := w.changed
+	w.changed = false
+	if err := w.sanitizeRecursive(cp, policy); err != nil {
+		w.changed = w.changed || changed
+		return err
+	}
+
+	if w.changed && !w.dryRun {
+		rv.Set(cp)
+	}
+	w.changed = w.changed || changed
+	return nil
+}
+
+// pushField appends a field name to the path of the walker, returning the
+// length to restore with pop.
+func (w *walker) pushField(name string) int {
+	n := len(w.path)
+	if n > 0 {
+		w.path = append(w.path, '.')
+	}
+	w.path = append(w.path, name...)
+	return n
+}
+
+// pushIndex appends a slice or array index to the path of the walker.
+func (w *walker) pushIndex(i int) int {
+	n := len(w.path)
+	w.path = append(w.path, '[')
+	w.path = strconv.AppendInt(w.path, int64(i), 10)
+	w.path = append(w.path, ']')
+	return n
+}
+
+// pushKey appends a map key to the path of the walker.
+func (w *walker) pushKey(key reflect.Value) int {
+	n := len(w.path)
+	w.path = append(w.path, '[')
+	switch key.Kind() {
+	case reflect.String:
+		w.path = append(w.path, key.String()...)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w.path = strconv.AppendInt(w.path, key.Int(), 10)
+	default:
+		w.path = fmt.Appendf(w.path, "%v", key)
+	}
+	w.path = append(w.path, ']')
+	return n
+}
+
+// pop truncates the path of the walker to the given length.
+func (w *walker) pop(n int) {
+	w.path = w.path[:n]
+}
+
+// joinPath appends a field name to a dotted field path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// indexPath appends a slice or array index to a field path.
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// keyPath appends a map key to a field path.
+func keyPath(path string, key reflect.Value) string {
+	switch key.Kind() {
+	case reflect.String:
+		return path + "[" + key.String() + "]"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return path + "[" + strconv.FormatInt(key.Int(), 10) + "]"
 	}
-	return s.sanitizeRecursive(reflect.ValueOf(rv.Interface()))
+	return fmt.Sprintf("%s[%v]", path, key)
 }
@@ -1,8 +1,19 @@
 package stzr_test
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/kraciasty/stzr"
 	"github.com/microcosm-cc/bluemonday"
@@ -128,6 +139,207 @@ func TestSanitizer_SanitizeString(t *testing.T) {
 	}
 }
 
+func TestSanitizer_SanitizeStringMulti(t *testing.T) {
+	s := stzr.NewWithDefaults(
+		stzr.WithPolicy("trim", stzr.PolicyFunc(strings.TrimSpace)),
+		stzr.WithPolicy("nourls", stzr.Reject(`https?://`, "links are not allowed")),
+	)
+
+	tests := []struct {
+		name     string
+		policies []string
+		input    string
+		want     string
+		err      string
+	}{
+		{name: "chain", policies: []string{"strict", "trim"}, input: "  <b>Rick</b> ", want: "Rick"},
+		{name: "order matters", policies: []string{"strict", "escape"}, input: "<b>&</b>", want: "&amp;amp;"},
+		{name: "no policies", input: "<b>Rick</b>", want: "<b>Rick</b>"},
+		{name: "unknown policy", policies: []string{"strict", "unknown"}, input: "x", err: `policy "unknown": sanitization policy not found`},
+		{name: "rejected", policies: []string{"strict", "nourls"}, input: "http://x", err: `policy "nourls": sanitization input rejected`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.SanitizeStringMulti(tt.policies, tt.input)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSanitizer_SanitizeStrings(t *testing.T) {
+	s := stzr.NewWithDefaults(stzr.WithPolicy("nourls", stzr.Reject(`https?://`, "links are not allowed")))
+
+	tests := []struct {
+		name   string
+		policy string
+		inputs []string
+		want   []string
+		err    string
+	}{
+		{name: "in order", policy: "strict", inputs: []string{"<b>rick</b>", "morty", "<i>summer</i>"}, want: []string{"rick", "morty", "summer"}},
+		{name: "no inputs", policy: "strict", want: []string{}},
+		{name: "unknown policy", policy: "unknown", inputs: []string{"x"}, err: "sanitization policy not found"},
+		{name: "rejected", policy: "nourls", inputs: []string{"rick", "http://x"}, err: "[1]: sanitization input rejected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.SanitizeStrings(tt.policy, tt.inputs...)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSanitizer_SanitizeBytes(t *testing.T) {
+	s := stzr.New(
+		stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+		stzr.WithPolicy("upper", stzr.PolicyFunc(strings.ToUpper)),
+	)
+
+	tests := []struct {
+		name   string
+		policy string
+		input  []byte
+		want   []byte
+		errIs  error
+	}{
+		{
+			name:   "bluemonday policy",
+			policy: "strict",
+			input:  []byte("<script>alert('xss')</script>Hello <b>World</b>"),
+			want:   []byte("Hello World"),
+		},
+		{
+			name:   "policy func",
+			policy: "upper",
+			input:  []byte("Hello <b>World</b>"),
+			want:   []byte("HELLO <B>WORLD</B>"),
+		},
+		{
+			name:   "unknown policy",
+			policy: "unknown",
+			input:  []byte("Hello"),
+			errIs:  stzr.ErrPolicyNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.SanitizeBytes(tt.policy, tt.input)
+			if tt.errIs != nil {
+				assert.ErrorIs(t, err, tt.errIs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSanitizer_SanitizeReader(t *testing.T) {
+	s := stzr.New(
+		stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+		stzr.WithPolicy("upper", stzr.PolicyFunc(strings.ToUpper)),
+	)
+
+	tests := []struct {
+		name   string
+		policy string
+		input  string
+		want   string
+		errIs  error
+	}{
+		{
+			name:   "bluemonday policy",
+			policy: "strict",
+			input:  "<script>alert('xss')</script>Hello <b>World</b>",
+			want:   "Hello World",
+		},
+		{
+			name:   "policy func",
+			policy: "upper",
+			input:  "Hello <b>World</b>",
+			want:   "HELLO <B>WORLD</B>",
+		},
+		{
+			name:   "unknown policy",
+			policy: "unknown",
+			input:  "Hello",
+			errIs:  stzr.ErrPolicyNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			err := s.SanitizeReader(tt.policy, strings.NewReader(tt.input), &out)
+			if tt.errIs != nil {
+				assert.ErrorIs(t, err, tt.errIs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+
+	t.Run("buffer pool", func(t *testing.T) {
+		var created int
+		pool := &sync.Pool{New: func() any {
+			created++
+			return new(bytes.Buffer)
+		}}
+		pooled := stzr.New(
+			stzr.WithPolicy("upper", stzr.PolicyFunc(strings.ToUpper)),
+			stzr.WithBufferPool(pool),
+		)
+
+		for _, input := range []string{"first", "second", "third"} {
+			var out strings.Builder
+			require.NoError(t, pooled.SanitizeReader("upper", strings.NewReader(input), &out))
+			assert.Equal(t, strings.ToUpper(input), out.String())
+		}
+		// The pool may drop buffers at any GC, so reuse is not guaranteed.
+		assert.Positive(t, created, "buffers come from the pool")
+		assert.LessOrEqual(t, created, 3)
+
+		created = 0
+		pooled = stzr.New(stzr.WithBufferPool(&sync.Pool{New: pool.New}))
+		shout := stzr.BufferedPolicyFunc(func(buf *bytes.Buffer, s string) {
+			buf.WriteString(strings.ToUpper(s))
+			buf.WriteByte('!')
+		})
+		require.NoError(t, pooled.AddPolicy("shout", shout))
+		input := struct {
+			Names []string `sanitize:"shout"`
+		}{Names: []string{"rick", "morty", "summer"}}
+		require.NoError(t, pooled.SanitizeStruct(&input))
+		assert.Equal(t, []string{"RICK!", "MORTY!", "SUMMER!"}, input.Names)
+		assert.Equal(t, "BETH!", shout.Sanitize("beth"), "works without a pool")
+		assert.Positive(t, created, "policies get buffers from the pool")
+		assert.LessOrEqual(t, created, len(input.Names))
+
+		empty := stzr.New(
+			stzr.WithPolicy("upper", stzr.PolicyFunc(strings.ToUpper)),
+			stzr.WithBufferPool(&sync.Pool{}),
+		)
+		var out strings.Builder
+		require.NoError(t, empty.SanitizeReader("upper", strings.NewReader("x"), &out))
+		assert.Equal(t, "X", out.String())
+	})
+}
+
 func TestSanitizer_SanitizeStruct(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -228,6 +440,69 @@ func TestSanitizer_SanitizeStruct(t *testing.T) {
 				assert.Equal(t, "Item 2", input.Items[1].Content)
 			},
 		},
+		{
+			name: "string arrays",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type code string
+
+				input := struct {
+					Codes   [3]string  `sanitize:"strict"`
+					Named   [2]code    `sanitize:"ugc"`
+					Empty   [0]string  `sanitize:"strict"`
+					Pointer *[2]string `sanitize:"strict"`
+					Nested  [1][2]string
+				}{
+					Codes:   [3]string{"<b>A1</b>", "B2", "<script>x</script>C3"},
+					Named:   [2]code{"<b>X</b><script>x</script>", "<i>Y</i>"},
+					Pointer: &[2]string{"<b>P</b>", ""},
+					Nested:  [1][2]string{{"<b>untagged</b>"}},
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, [3]string{"A1", "B2", "C3"}, input.Codes)
+				assert.Equal(t, [2]code{"<b>X</b>", "<i>Y</i>"}, input.Named)
+				assert.Equal(t, [0]string{}, input.Empty)
+				assert.Equal(t, &[2]string{"P", ""}, input.Pointer)
+				assert.Equal(t, [1][2]string{{"<b>untagged</b>"}}, input.Nested)
+			},
+		},
+		{
+			name: "pointers to slices and maps",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type item struct {
+					Content string `sanitize:"strict"`
+				}
+
+				items := []item{{Content: "<b>slice</b>"}}
+				data := map[string]item{"a": {Content: "<b>map</b>"}}
+				tags := []string{"<b>tag</b>", "<i>other</i>"}
+				meta := map[string]string{"a": "<b>value</b>"}
+				input := struct {
+					Items    *[]item
+					Data     *map[string]item
+					Tags     *[]string          `sanitize:"strict"`
+					Meta     *map[string]string `sanitize:"strict"`
+					Untagged *[]string
+					NilItems *[]item
+					NilTags  *[]string `sanitize:"strict"`
+				}{
+					Items:    &items,
+					Data:     &data,
+					Tags:     &tags,
+					Meta:     &meta,
+					Untagged: &[]string{"<b>raw</b>"},
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, []item{{Content: "slice"}}, items)
+				assert.Equal(t, map[string]item{"a": {Content: "map"}}, data)
+				assert.Equal(t, []string{"tag", "other"}, tags)
+				assert.Equal(t, map[string]string{"a": "value"}, meta)
+				assert.Equal(t, &[]string{"<b>raw</b>"}, input.Untagged)
+				assert.Nil(t, input.NilItems)
+				assert.Nil(t, input.NilTags)
+			},
+		},
 		{
 			name: "maps",
 			run: func(t *testing.T, s *stzr.Sanitizer) {
@@ -249,6 +524,33 @@ func TestSanitizer_SanitizeStruct(t *testing.T) {
 				assert.Equal(t, "Value 2", input.Data["key2"].Content)
 			},
 		},
+		{
+			name: "maps of pointers",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type item struct {
+					Content string `sanitize:"strict"`
+				}
+
+				shared := &item{Content: "<b>shared</b>"}
+				input := struct {
+					Data map[string]*item
+				}{
+					Data: map[string]*item{
+						"a":   {Content: "<b>a</b>"},
+						"b":   shared,
+						"c":   shared,
+						"nil": nil,
+					},
+				}
+
+				changed, err := s.SanitizeStructReport(&input)
+				require.NoError(t, err)
+				assert.True(t, changed)
+				assert.Equal(t, map[string]*item{"a": {Content: "a"}, "b": shared, "c": shared, "nil": nil}, input.Data)
+				assert.Same(t, shared, input.Data["b"])
+				assert.Equal(t, "shared", shared.Content)
+			},
+		},
 		{
 			name: "interfaces",
 			run: func(t *testing.T, s *stzr.Sanitizer) {
@@ -268,6 +570,36 @@ func TestSanitizer_SanitizeStruct(t *testing.T) {
 				assert.Equal(t, "Interface Content", input.Data.(*concreteStruct).Content)
 			},
 		},
+		{
+			name: "named interfaces",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				input := struct {
+					Value    character
+					Pointer  character
+					Named    character `sanitize:"strict"`
+					Slice    []character
+					Map      map[string]character
+					Nil      character
+					Stringer fmt.Stringer
+				}{
+					Value:    scientist{Bio: "<b>Rick</b>"},
+					Pointer:  &scientist{Bio: "<b>Morty</b>"},
+					Named:    nickname("<i>Pickle</i>"),
+					Slice:    []character{scientist{Bio: "<b>Beth</b>"}},
+					Map:      map[string]character{"a": scientist{Bio: "<b>Summer</b>"}},
+					Stringer: scientist{Bio: "<b>Jerry</b>"},
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, scientist{Bio: "Rick"}, input.Value)
+				assert.Equal(t, &scientist{Bio: "Morty"}, input.Pointer)
+				assert.Equal(t, nickname("Pickle"), input.Named)
+				assert.Equal(t, []character{scientist{Bio: "Beth"}}, input.Slice)
+				assert.Equal(t, map[string]character{"a": scientist{Bio: "Summer"}}, input.Map)
+				assert.Nil(t, input.Nil)
+				assert.Equal(t, scientist{Bio: "Jerry"}, input.Stringer)
+			},
+		},
 		{
 			name: "nil interface",
 			run: func(t *testing.T, s *stzr.Sanitizer) {
@@ -354,430 +686,3089 @@ func TestSanitizer_SanitizeStruct(t *testing.T) {
 			},
 		},
 		{
-			name:    "remove policy",
-			options: []stzr.Opt{stzr.WithPolicy("removeme", bluemonday.UGCPolicy())},
-			setup: func(s *stzr.Sanitizer) {
-				result, err := s.SanitizeString("removeme", "<b>test</b>")
-				require.NoError(t, err)
-				assert.Equal(t, "<b>test</b>", result)
+			name: "field hook",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type call struct {
+					path, policy, before, after string
+				}
+
+				var calls []call
+				var hooked *stzr.Sanitizer
+				hooked = stzr.New(
+					stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+					stzr.WithFieldHook(func(path, policy, before, after string) {
+						calls = append(calls, call{path, policy, before, after})
+						// The hook must be able to call back into the sanitizer.
+						_, err := hooked.SanitizeString(policy, before)
+						require.NoError(t, err)
+					}),
+				)
+
+				type item struct {
+					Content string `sanitize:"strict"`
+				}
+
+				input := struct {
+					Title string `sanitize:"strict"`
+					Items []item
+					Plain string
+				}{
+					Title: "<b>Title</b>",
+					Items: []item{{Content: "<i>Item</i>"}},
+					Plain: "<b>Plain</b>",
+				}
+
+				require.NoError(t, hooked.SanitizeStruct(&input))
+				assert.Equal(t, []call{
+					{"Title", "strict", "<b>Title</b>", "Title"},
+					{"Items[0].Content", "strict", "<i>Item</i>", "Item"},
+				}, calls)
+			},
+		},
+		{
+			name:    "default policy",
+			options: []stzr.Opt{stzr.WithDefaultPolicy("strict")},
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type nested struct {
+					Content string
+				}
+
+				input := struct {
+					Untagged string
+					Tagged   string `sanitize:"ugc"`
+					Skipped  string `sanitize:"-"`
+					Nested   nested
+				}{
+					Untagged: "<b>Untagged</b>",
+					Tagged:   "<b>Tagged</b>",
+					Skipped:  "<b>Skipped</b>",
+					Nested:   nested{Content: "<b>Nested</b>"},
+				}
 
-				s.Remove("removeme")
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "Untagged", input.Untagged)
+				assert.Equal(t, "<b>Tagged</b>", input.Tagged)
+				assert.Equal(t, "<b>Skipped</b>", input.Skipped)
+				assert.Equal(t, "Nested", input.Nested.Content)
 			},
+		},
+		{
+			name:    "unknown default policy error",
+			options: []stzr.Opt{stzr.WithDefaultPolicy("unknown")},
 			wantErr: true,
 			run: func(t *testing.T, s *stzr.Sanitizer) {
 				input := struct {
-					Field string `sanitize:"removeme"`
+					Field string
 				}{
-					Field: "<b>test</b>",
+					Field: "test",
 				}
 
 				err := s.SanitizeStruct(&input)
-				assert.Error(t, err)
-				assert.Contains(t, err.Error(), "policy \"removeme\": sanitization policy not found")
+				assert.ErrorIs(t, err, stzr.ErrPolicyNotFound)
 			},
 		},
 		{
-			name: "reserved policy panic with WithPolicy",
+			name: "policy alias",
+			setup: func(s *stzr.Sanitizer) {
+				s.Alias("plain", "strict")
+				s.Alias("legacy", "plain")
+			},
 			run: func(t *testing.T, s *stzr.Sanitizer) {
-				assert.Panics(t, func() {
-					stzr.New(stzr.WithPolicy("-", bluemonday.StrictPolicy()))
-				})
+				input := struct {
+					Plain  string `sanitize:"plain"`
+					Legacy string `sanitize:"legacy"`
+				}{
+					Plain:  "<b>Plain</b>",
+					Legacy: "<b>Legacy</b>",
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "Plain", input.Plain)
+				assert.Equal(t, "Legacy", input.Legacy)
 			},
 		},
 		{
-			name: "reserved policy panic with Add",
+			name: "policy alias cycle",
+			setup: func(s *stzr.Sanitizer) {
+				s.Alias("a", "b")
+				s.Alias("b", "a")
+			},
+			wantErr: true,
 			run: func(t *testing.T, s *stzr.Sanitizer) {
-				assert.Panics(t, func() {
-					s.Add("-", bluemonday.StrictPolicy())
-				})
+				_, err := s.SanitizeString("a", "test")
+				assert.ErrorIs(t, err, stzr.ErrAliasCycle)
 			},
 		},
 		{
-			name:    "nil pointer input",
+			name: "remove policy alias",
+			setup: func(s *stzr.Sanitizer) {
+				s.Alias("plain", "strict")
+				assert.True(t, s.Remove("plain"))
+			},
 			wantErr: true,
 			run: func(t *testing.T, s *stzr.Sanitizer) {
-				var nilPtr *struct {
-					Field string `sanitize:"strict"`
-				}
-				err := s.SanitizeStruct(nilPtr)
-				assert.Error(t, err)
-				assert.Contains(t, err.Error(), "expected pointer to struct")
+				_, err := s.SanitizeString("plain", "test")
+				assert.ErrorIs(t, err, stzr.ErrPolicyNotFound)
 			},
 		},
 		{
-			name: "zero value struct",
+			name: "reserved alias panic",
 			run: func(t *testing.T, s *stzr.Sanitizer) {
-				input := &struct {
-					Field string `sanitize:"strict"`
-				}{}
-				err := s.SanitizeStruct(input)
-				require.NoError(t, err)
+				assert.Panics(t, func() {
+					s.Alias("-", "strict")
+				})
 			},
 		},
 		{
-			name: "unexported fields",
+			name:    "policy not found error details",
+			wantErr: true,
 			run: func(t *testing.T, s *stzr.Sanitizer) {
-				input := &struct {
-					ExportedField   string `sanitize:"strict"`
-					unexportedField string `sanitize:"strict"`
+				type item struct {
+					Content string `sanitize:"unknown"`
+				}
+
+				input := struct {
+					Items []item
 				}{
-					ExportedField:   "<script>alert('xss')</script>Exported",
-					unexportedField: "<script>alert('xss')</script>Unexported",
+					Items: []item{{Content: "test"}},
 				}
 
-				err := s.SanitizeStruct(input)
-				require.NoError(t, err)
-				assert.Equal(t, "Exported", input.ExportedField)
-				assert.Equal(t, "<script>alert('xss')</script>Unexported", input.unexportedField)
+				err := s.SanitizeStruct(&input)
+				require.ErrorIs(t, err, stzr.ErrPolicyNotFound)
+
+				var notFound *stzr.PolicyNotFoundError
+				require.ErrorAs(t, err, &notFound)
+				assert.Equal(t, "unknown", notFound.Name)
+				assert.Equal(t, "Items[0].Content", notFound.Path)
+				assert.Equal(t, `Items[0].Content: policy "unknown": sanitization policy not found`, err.Error())
 			},
 		},
 		{
-			name: "arrays",
-			run: func(t *testing.T, s *stzr.Sanitizer) {
-				input := struct {
-					Items [2]struct {
-						Content string `sanitize:"strict"`
+			name: "policy resolver",
+			options: []stzr.Opt{
+				stzr.WithPolicyResolver(func(sf reflect.StructField, parent reflect.Value) (string, bool) {
+					if sf.Name != "Body" {
+						return "", false
 					}
-				}{
-					Items: [2]struct {
-						Content string `sanitize:"strict"`
-					}{
-						{Content: "<script>alert('xss')</script>Item 1"},
-						{Content: "<script>alert('xss')</script>Item 2"},
-					},
+					if parent.FieldByName("ContentType").String() == "html" {
+						return "ugc", true
+					}
+					return "strict", true
+				}),
+			},
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type document struct {
+					ContentType string
+					Body        string `sanitize:"-"`
+					Title       string `sanitize:"strict"`
 				}
 
-				err := s.SanitizeStruct(&input)
-				require.NoError(t, err)
-				assert.Equal(t, "Item 1", input.Items[0].Content)
-				assert.Equal(t, "Item 2", input.Items[1].Content)
+				html := document{ContentType: "html", Body: "<b>Body</b>", Title: "<b>Title</b>"}
+				text := document{ContentType: "text", Body: "<b>Body</b>", Title: "<b>Title</b>"}
+
+				require.NoError(t, s.SanitizeStruct(&html))
+				require.NoError(t, s.SanitizeStruct(&text))
+				assert.Equal(t, "<b>Body</b>", html.Body)
+				assert.Equal(t, "Title", html.Title)
+				assert.Equal(t, "Body", text.Body)
+				assert.Equal(t, "Title", text.Title)
 			},
 		},
 		{
-			name: "empty slice",
+			name: "tagged free-form map",
 			run: func(t *testing.T, s *stzr.Sanitizer) {
 				input := struct {
-					Items []struct {
-						Content string `sanitize:"strict"`
-					}
+					Payload map[string]any `sanitize:"strict"`
 				}{
-					Items: []struct {
-						Content string `sanitize:"strict"`
-					}{},
+					Payload: map[string]any{
+						"name":  "<b>Rick</b>",
+						"count": 42,
+						"nested": map[string]any{
+							"bio": "<i>Genius</i>",
+						},
+						"list": []any{"<b>one</b>", 2, map[string]any{"three": "<b>three</b>"}},
+					},
 				}
 
-				err := s.SanitizeStruct(&input)
-				require.NoError(t, err)
-				assert.Empty(t, input.Items)
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, map[string]any{
+					"name":  "Rick",
+					"count": 42,
+					"nested": map[string]any{
+						"bio": "Genius",
+					},
+					"list": []any{"one", 2, map[string]any{"three": "three"}},
+				}, input.Payload)
 			},
 		},
 		{
-			name: "nil slice",
+			name:    "free-form map with default policy",
+			options: []stzr.Opt{stzr.WithDefaultPolicy("strict")},
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				input := map[string]any{
+					"name":   "<b>Rick</b>",
+					"nested": map[string]any{"bio": "<i>Genius</i>"},
+					"list":   []any{"<b>one</b>"},
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, map[string]any{
+					"name":   "Rick",
+					"nested": map[string]any{"bio": "Genius"},
+					"list":   []any{"one"},
+				}, input)
+			},
+		},
+		{
+			name: "untagged free-form map",
 			run: func(t *testing.T, s *stzr.Sanitizer) {
 				input := struct {
-					Items []struct {
-						Content string `sanitize:"strict"`
-					}
+					Payload map[string]any
 				}{
-					Items: nil,
+					Payload: map[string]any{"name": "<b>Rick</b>"},
 				}
 
-				err := s.SanitizeStruct(&input)
-				require.NoError(t, err)
-				assert.Nil(t, input.Items)
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "<b>Rick</b>", input.Payload["name"])
 			},
 		},
 		{
-			name: "empty map",
+			name: "tagged string collections",
 			run: func(t *testing.T, s *stzr.Sanitizer) {
 				input := struct {
-					Data map[string]struct {
-						Content string `sanitize:"strict"`
-					}
+					Tags   []string          `sanitize:"strict"`
+					Labels map[string]string `sanitize:"strict"`
+					Any    any               `sanitize:"strict"`
 				}{
-					Data: make(map[string]struct {
-						Content string `sanitize:"strict"`
-					}),
+					Tags:   []string{"<b>one</b>", "<i>two</i>"},
+					Labels: map[string]string{"<b>key</b>": "<b>value</b>"},
+					Any:    "<b>any</b>",
 				}
 
-				err := s.SanitizeStruct(&input)
-				require.NoError(t, err)
-				assert.Empty(t, input.Data)
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, []string{"one", "two"}, input.Tags)
+				assert.Equal(t, map[string]string{"<b>key</b>": "value"}, input.Labels)
+				assert.Equal(t, "any", input.Any)
 			},
 		},
 		{
-			name: "nil map",
+			name:    "inline allow option",
+			options: []stzr.Opt{stzr.WithPolicyBuilder("builder", bluemonday.StrictPolicy)},
 			run: func(t *testing.T, s *stzr.Sanitizer) {
 				input := struct {
-					Data map[string]struct {
-						Content string `sanitize:"strict"`
-					}
+					Plain   string `sanitize:"builder"`
+					Abbr    string `sanitize:"builder;allow=abbr"`
+					AbbrAll string `sanitize:"builder;allow=abbr,allow=cite"`
 				}{
-					Data: nil,
+					Plain:   "<abbr>HTML</abbr> <cite>Rick</cite>",
+					Abbr:    "<abbr>HTML</abbr> <cite>Rick</cite>",
+					AbbrAll: "<abbr>HTML</abbr> <cite>Rick</cite>",
 				}
 
-				err := s.SanitizeStruct(&input)
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "HTML Rick", input.Plain)
+				assert.Equal(t, "<abbr>HTML</abbr> Rick", input.Abbr)
+				assert.Equal(t, "<abbr>HTML</abbr> <cite>Rick</cite>", input.AbbrAll)
+
+				// The base policy is left untouched by derived policies.
+				result, err := s.SanitizeString("builder", "<abbr>HTML</abbr>")
 				require.NoError(t, err)
-				assert.Nil(t, input.Data)
+				assert.Equal(t, "HTML", result)
 			},
 		},
 		{
-			name: "deeply nested pointers",
+			name:    "inline allow option on alias",
+			options: []stzr.Opt{stzr.WithPolicyBuilder("strict", bluemonday.StrictPolicy)},
+			setup: func(s *stzr.Sanitizer) {
+				s.Alias("old", "strict")
+				s.Alias("older", "old")
+			},
 			run: func(t *testing.T, s *stzr.Sanitizer) {
-				type Level3 struct {
-					Content string `sanitize:"strict"`
-				}
-				type Level2 struct {
-					Level3Ptr *Level3
-				}
-				type Level1 struct {
-					Level2Ptr *Level2
+				input := struct {
+					Old   string `sanitize:"old;allow=b"`
+					Older string `sanitize:"older;allow=b"`
+				}{
+					Old:   "<b>Rick</b> <i>Sanchez</i>",
+					Older: "<b>Morty</b> <i>Smith</i>",
 				}
 
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "<b>Rick</b> Sanchez", input.Old)
+				assert.Equal(t, "<b>Morty</b> Smith", input.Older)
+			},
+		},
+		{
+			name:    "inline options without builder",
+			wantErr: true,
+			run: func(t *testing.T, s *stzr.Sanitizer) {
 				input := struct {
-					Level1Ptr *Level1
+					Field string `sanitize:"ugc;allow=abbr"`
 				}{
-					Level1Ptr: &Level1{
-						Level2Ptr: &Level2{
-							Level3Ptr: &Level3{
-								Content: "<script>alert('xss')</script>Deep Content",
-							},
-						},
-					},
+					Field: "<abbr>HTML</abbr>",
 				}
 
 				err := s.SanitizeStruct(&input)
-				require.NoError(t, err)
-				assert.Equal(t, "Deep Content", input.Level1Ptr.Level2Ptr.Level3Ptr.Content)
+				assert.ErrorIs(t, err, stzr.ErrNotDerivable)
 			},
 		},
 		{
-			name: "interface with nested struct",
+			name:    "inline options with unknown policy",
+			wantErr: true,
 			run: func(t *testing.T, s *stzr.Sanitizer) {
-				type NestedStruct struct {
-					Content string `sanitize:"strict"`
-				}
-
-				interfaceValue := &NestedStruct{
-					Content: "<script>alert('xss')</script>Interface Content",
+				input := struct {
+					Field string `sanitize:"unknown;allow=abbr"`
+				}{
+					Field: "<abbr>HTML</abbr>",
 				}
 
+				err := s.SanitizeStruct(&input)
+				assert.ErrorIs(t, err, stzr.ErrPolicyNotFound)
+			},
+		},
+		{
+			name:    "invalid inline option",
+			wantErr: true,
+			run: func(t *testing.T, s *stzr.Sanitizer) {
 				input := struct {
-					Data any
+					Field string `sanitize:"strict;bogus=1"`
 				}{
-					Data: interfaceValue,
+					Field: "test",
 				}
 
 				err := s.SanitizeStruct(&input)
-				require.NoError(t, err)
-
-				nested, ok := input.Data.(*NestedStruct)
-				require.True(t, ok)
-				assert.Equal(t, "Interface Content", nested.Content)
+				assert.ErrorIs(t, err, stzr.ErrInvalidTag)
+				assert.Contains(t, err.Error(), "Field")
 			},
 		},
 		{
-			name: "various reflect kinds not handled",
+			name: "map key and value policies",
 			run: func(t *testing.T, s *stzr.Sanitizer) {
 				input := struct {
-					IntField    int
-					FloatField  float64
-					BoolField   bool
-					StringField string `sanitize:"strict"`
+					Both    map[string]string `sanitize:"key=strict,val=ugc"`
+					KeyOnly map[string]string `sanitize:"key=strict"`
+					ValOnly map[string]string `sanitize:"val=strict"`
+					Nil     map[string]string `sanitize:"key=strict,val=ugc"`
 				}{
-					IntField:    42,
-					FloatField:  3.14,
-					BoolField:   true,
-					StringField: "<script>alert('xss')</script>String",
+					Both:    map[string]string{"<b>key</b>": "<b>value</b><script>x</script>"},
+					KeyOnly: map[string]string{"<b>key</b>": "<b>value</b>"},
+					ValOnly: map[string]string{"<b>key</b>": "<b>value</b>"},
 				}
 
-				err := s.SanitizeStruct(&input)
-				require.NoError(t, err)
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, map[string]string{"key": "<b>value</b>"}, input.Both)
+				assert.Equal(t, map[string]string{"key": "<b>value</b>"}, input.KeyOnly)
+				assert.Equal(t, map[string]string{"<b>key</b>": "value"}, input.ValOnly)
+				assert.Nil(t, input.Nil)
+			},
+		},
+		{
+			name: "map key collisions",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				input := struct {
+					CleanWins    map[string]int `sanitize:"key=strict"`
+					SmallestWins map[string]int `sanitize:"key=strict"`
+				}{
+					CleanWins:    map[string]int{"<b>key</b>": 1, "key": 2, "<i>key</i>": 3},
+					SmallestWins: map[string]int{"<i>key</i>": 1, "<b>key</b>": 2},
+				}
 
-				assert.Equal(t, 42, input.IntField)
-				assert.Equal(t, 3.14, input.FloatField)
-				assert.Equal(t, true, input.BoolField)
-				assert.Equal(t, "String", input.StringField)
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, map[string]int{"key": 2}, input.CleanWins)
+				assert.Equal(t, map[string]int{"key": 2}, input.SmallestWins)
 			},
 		},
 		{
-			name: "channel and function fields",
+			name:    "key and val options on string field",
+			wantErr: true,
 			run: func(t *testing.T, s *stzr.Sanitizer) {
 				input := struct {
-					ChannelField chan int
-					FuncField    func() string
-					StringField  string `sanitize:"strict"`
+					Field string `sanitize:"key=strict"`
 				}{
-					ChannelField: make(chan int),
-					FuncField:    func() string { return "test" },
-					StringField:  "<script>alert('xss')</script>String",
+					Field: "test",
 				}
 
 				err := s.SanitizeStruct(&input)
-				require.NoError(t, err)
-
-				assert.NotNil(t, input.ChannelField)
-				assert.NotNil(t, input.FuncField)
-				assert.Equal(t, "String", input.StringField)
+				assert.ErrorIs(t, err, stzr.ErrInvalidTag)
 			},
 		},
 		{
-			name: "untagged fields with tagged nested",
+			name: "nested collections in maps",
 			run: func(t *testing.T, s *stzr.Sanitizer) {
-				type nestedStruct struct {
+				type item struct {
 					Content string `sanitize:"strict"`
 				}
 
+				type wrapper struct {
+					Items []item
+				}
+
 				input := struct {
-					TaggedField    string `sanitize:"strict"`
-					UntaggedField  string
-					UntaggedNested nestedStruct
+					Slices   map[string][]item
+					Arrays   map[string][1]item
+					Maps     map[string]map[string]item
+					Wrappers map[string]wrapper
+					Deep     map[string]map[string][]map[string]item
 				}{
-					TaggedField:   "<script>alert('xss')</script>Tagged",
-					UntaggedField: "<script>alert('xss')</script>Untagged",
-					UntaggedNested: nestedStruct{
-						Content: "<script>alert('xss')</script>Nested Content",
+					Slices:   map[string][]item{"a": {{Content: "<b>slice</b>"}}},
+					Arrays:   map[string][1]item{"a": {{Content: "<b>array</b>"}}},
+					Maps:     map[string]map[string]item{"a": {"b": {Content: "<b>map</b>"}}},
+					Wrappers: map[string]wrapper{"a": {Items: []item{{Content: "<b>wrapper</b>"}}}},
+					Deep: map[string]map[string][]map[string]item{
+						"a": {"b": {{"c": {Content: "<b>deep</b>"}}}},
 					},
 				}
 
 				require.NoError(t, s.SanitizeStruct(&input))
-				assert.Equal(t, "Tagged", input.TaggedField)
-				assert.Equal(t, "<script>alert('xss')</script>Untagged", input.UntaggedField)
-				assert.Equal(t, "Nested Content", input.UntaggedNested.Content)
+				assert.Equal(t, "slice", input.Slices["a"][0].Content)
+				assert.Equal(t, "array", input.Arrays["a"][0].Content)
+				assert.Equal(t, "map", input.Maps["a"]["b"].Content)
+				assert.Equal(t, "wrapper", input.Wrappers["a"].Items[0].Content)
+				assert.Equal(t, "deep", input.Deep["a"]["b"][0]["c"].Content)
 			},
 		},
 		{
-			name: "untagged slice with tagged elements",
+			name: "map of slices of structs",
 			run: func(t *testing.T, s *stzr.Sanitizer) {
-				type nestedStruct struct {
-					Content string `sanitize:"strict"`
+				type item struct {
+					Title string `sanitize:"strict"`
+					Body  string `sanitize:"ugc"`
 				}
 
-				input := struct {
-					TaggedField   string `sanitize:"strict"`
-					UntaggedSlice []nestedStruct
-				}{
-					TaggedField: "<script>alert('xss')</script>Tagged",
-					UntaggedSlice: []nestedStruct{
-						{Content: "<script>alert('xss')</script>Slice Content 1"},
-						{Content: "<script>alert('xss')</script>Slice Content 2"},
-					},
+				input := map[string][]item{
+					"a": {{Title: "<b>one</b>", Body: "<b>one</b><script>x</script>"}, {Title: "clean"}},
+					"b": {{Title: "<i>two</i>"}},
+					"c": nil,
 				}
+				backing := input["a"]
+				nested := []map[string][]item{input}
+				boxed := map[string]any{"items": input}
 
 				require.NoError(t, s.SanitizeStruct(&input))
-				assert.Equal(t, "Tagged", input.TaggedField)
-				assert.Equal(t, "Slice Content 1", input.UntaggedSlice[0].Content)
-				assert.Equal(t, "Slice Content 2", input.UntaggedSlice[1].Content)
+				assert.Equal(t, map[string][]item{
+					"a": {{Title: "one", Body: "<b>one</b>"}, {Title: "clean"}},
+					"b": {{Title: "two"}},
+					"c": nil,
+				}, input)
+				assert.Equal(t, "one", backing[0].Title)
+
+				input["b"][0].Title = "<i>again</i>"
+				require.NoError(t, s.SanitizeStruct(&nested))
+				assert.Equal(t, "again", nested[0]["b"][0].Title)
+
+				input["b"][0].Title = "<i>boxed</i>"
+				require.NoError(t, s.SanitizeStruct(&boxed))
+				assert.Equal(t, "boxed", input["b"][0].Title)
 			},
 		},
 		{
-			name: "untagged map with tagged elements",
+			name: "collections held by interfaces",
 			run: func(t *testing.T, s *stzr.Sanitizer) {
-				type nestedStruct struct {
-					Content string `sanitize:"strict"`
+				type item struct {
+					Title string `sanitize:"strict"`
 				}
 
 				input := struct {
-					TaggedField string `sanitize:"strict"`
-					UntaggedMap map[string]nestedStruct
+					Slice  any
+					Map    any
+					Struct any
+					Array  any
+					Nested map[string]any
 				}{
-					TaggedField: "<script>alert('xss')</script>Tagged",
-					UntaggedMap: map[string]nestedStruct{
-						"key1": {Content: "<script>alert('xss')</script>Map Content 1"},
-						"key2": {Content: "<script>alert('xss')</script>Map Content 2"},
-					},
+					Slice:  []item{{Title: "<b>slice</b>"}},
+					Map:    map[string]item{"a": {Title: "<b>map</b>"}},
+					Struct: item{Title: "<b>struct</b>"},
+					Array:  [1]item{{Title: "<b>array</b>"}},
+					Nested: map[string]any{"a": item{Title: "<b>nested</b>"}},
 				}
 
 				require.NoError(t, s.SanitizeStruct(&input))
-				assert.Equal(t, "Tagged", input.TaggedField)
-				assert.Equal(t, "Map Content 1", input.UntaggedMap["key1"].Content)
-				assert.Equal(t, "Map Content 2", input.UntaggedMap["key2"].Content)
+				assert.Equal(t, []item{{Title: "slice"}}, input.Slice)
+				assert.Equal(t, map[string]item{"a": {Title: "map"}}, input.Map)
+				assert.Equal(t, item{Title: "struct"}, input.Struct)
+				assert.Equal(t, [1]item{{Title: "array"}}, input.Array)
+				assert.Equal(t, item{Title: "nested"}, input.Nested["a"])
 			},
 		},
 		{
-			name: "skip tag ignores nested",
+			name: "size limit",
 			run: func(t *testing.T, s *stzr.Sanitizer) {
-				type nestedStruct struct {
-					StrictField string `sanitize:"strict"`
-					UGCField    string `sanitize:"ugc"`
+				input := struct {
+					Name string `sanitize:"strict;max=5"`
+				}{Name: "<b>Rick</b>"}
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "Rick", input.Name)
+
+				oversized := struct {
+					Name string `sanitize:"strict;max=5"`
+				}{Name: "<b>Morty</b> Smith"}
+				err := s.SanitizeStruct(&oversized)
+				assert.ErrorIs(t, err, stzr.ErrTooLarge)
+
+				var sizeErr *stzr.SizeError
+				require.ErrorAs(t, err, &sizeErr)
+				assert.Equal(t, stzr.SizeError{Path: "Name", Size: 11, Limit: 5}, *sizeErr)
+				assert.Equal(t, "<b>Morty</b> Smith", oversized.Name)
+			},
+		},
+		{
+			name:    "escape mode",
+			options: []stzr.Opt{stzr.WithPolicy("trim", stzr.PolicyFunc(strings.TrimSpace))},
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				input := struct {
+					Stripped string   `sanitize:"ugc;mode=strip"`
+					Escaped  string   `sanitize:"ugc;mode=escape"`
+					Chained  string   `sanitize:"strict,trim;mode=escape"`
+					Twice    string   `sanitize:"strict,ugc;mode=escape"`
+					Tags     []string `sanitize:"strict;mode=escape"`
+				}{
+					Stripped: "<b>bold</b><script>x</script>",
+					Escaped:  "<b>bold</b><script>x</script>",
+					Chained:  "  <i>rick</i> & morty  ",
+					Twice:    "<b>x</b> & y",
+					Tags:     []string{"<b>tag</b>"},
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "<b>bold</b>", input.Stripped)
+				assert.Equal(t, "&lt;b&gt;bold&lt;/b&gt;&lt;script&gt;x&lt;/script&gt;", input.Escaped)
+				assert.Equal(t, "&lt;i&gt;rick&lt;/i&gt; &amp; morty", input.Chained)
+				assert.Equal(t, "&lt;b&gt;x&lt;/b&gt; &amp; y", input.Twice, "escaped once")
+				assert.Equal(t, []string{"&lt;b&gt;tag&lt;/b&gt;"}, input.Tags)
+
+				unknown := struct {
+					Name string `sanitize:"unknown;mode=escape"`
+				}{Name: "<b>Rick</b>"}
+				assert.ErrorIs(t, s.SanitizeStruct(&unknown), stzr.ErrPolicyNotFound, "policies are still resolved")
+			},
+		},
+		{
+			name: "required",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type post struct {
+					Title string   `sanitize:"strict;required"`
+					Tags  []string `sanitize:"strict;required"`
 				}
 
+				input := post{Title: "<b>Pickle</b>", Tags: []string{"", "<i>rick</i>"}}
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, post{Title: "Pickle", Tags: []string{"", "rick"}}, input)
+
+				emptied := post{Title: "<script>alert(1)</script>"}
+				err := s.SanitizeStruct(&emptied)
+				assert.ErrorIs(t, err, stzr.ErrEmptied)
+				assert.EqualError(t, err, `Title: policy "strict": required value emptied by sanitization`)
+				assert.Equal(t, "<script>alert(1)</script>", emptied.Title)
+
+				_, err = s.DryRun(&post{Title: "ok", Tags: []string{"<script>x</script>"}})
+				assert.ErrorIs(t, err, stzr.ErrEmptied)
+				assert.ErrorContains(t, err, "Tags[0]")
+			},
+		},
+		{
+			name: "max field size",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type post struct {
+					Title string            `sanitize:"strict"`
+					Meta  map[string]string `sanitize:"strict"`
+				}
+
+				limited := stzr.New(
+					stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+					stzr.WithMaxFieldSize(8),
+				)
+				input := post{Title: "<b>Rick</b>"}
+				err := limited.SanitizeStruct(&input)
+				assert.ErrorIs(t, err, stzr.ErrTooLarge)
+				var sizeErr *stzr.SizeError
+				require.ErrorAs(t, err, &sizeErr)
+				assert.Equal(t, stzr.SizeError{Path: "Title", Size: 11, Limit: 8}, *sizeErr)
+				assert.Equal(t, "<b>Rick</b>", input.Title)
+
+				input = post{Title: "Rick", Meta: map[string]string{"k": "123456789"}}
+				assert.ErrorContains(t, limited.SanitizeStruct(&input), "Meta[k]: 9 bytes exceeds limit of 8")
+
+				truncating := stzr.New(
+					stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+					stzr.WithMaxFieldSize(8),
+					stzr.WithOversizeMode(stzr.TruncateOversize),
+				)
+				input = post{Title: "Wubba lubba", Meta: map[string]string{"k": "Zażółć gęślą"}}
+				require.NoError(t, truncating.SanitizeStruct(&input))
+				assert.Equal(t, "Wubba lu", input.Title)
+				assert.Equal(t, "Zażół", input.Meta["k"])
+			},
+		},
+		{
+			name:    "free-form map key policy",
+			options: []stzr.Opt{stzr.WithKeyPolicy("strict")},
+			run: func(t *testing.T, s *stzr.Sanitizer) {
 				input := struct {
-					TaggedField   string       `sanitize:"strict"`
-					SkippedNested nestedStruct `sanitize:"-"`
+					Payload map[string]any    `sanitize:"ugc"`
+					Keyed   map[string]any    `sanitize:"key=ugc,val=strict"`
+					Typed   map[string]string `sanitize:"ugc"`
 				}{
-					TaggedField: "<script>alert('xss')</script>Tagged",
-					SkippedNested: nestedStruct{
-						StrictField: "<script>alert('xss')</script>Nested Strict",
-						UGCField:    "<script>alert('xss')</script>Nested UGC",
+					Payload: map[string]any{
+						"<b>name</b>": "<b>Rick</b><script>x</script>",
+						"nested": map[string]any{
+							"<i>a</i>": "<i>b</i>",
+							"list":     []any{map[string]any{"<b>deep</b>": 1}},
+						},
+						"<b>dup</b>": "dirty",
+						"dup":        "clean",
 					},
+					Keyed: map[string]any{"<b>kept</b>": "<b>v</b>"},
+					Typed: map[string]string{"<b>typed</b>": "v"},
 				}
 
 				require.NoError(t, s.SanitizeStruct(&input))
-				assert.Equal(t, "Tagged", input.TaggedField)
-				assert.Equal(t, "<script>alert('xss')</script>Nested Strict", input.SkippedNested.StrictField)
-				assert.Equal(t, "<script>alert('xss')</script>Nested UGC", input.SkippedNested.UGCField)
+				assert.Equal(t, map[string]any{
+					"name": "<b>Rick</b>",
+					"nested": map[string]any{
+						"a":    "<i>b</i>",
+						"list": []any{map[string]any{"deep": 1}},
+					},
+					"dup": "clean",
+				}, input.Payload)
+				assert.Equal(t, map[string]any{"<b>kept</b>": "v"}, input.Keyed)
+				assert.Equal(t, map[string]string{"<b>typed</b>": "v"}, input.Typed)
 			},
 		},
 		{
-			name: "completely untagged nested",
+			name: "deep tag",
 			run: func(t *testing.T, s *stzr.Sanitizer) {
-				type completelyUntagged struct {
-					Content string
+				type leaf struct {
+					Text  string
+					Own   string `sanitize:"ugc"`
+					Skip  string `sanitize:"-"`
+					Empty string `sanitize:""`
+				}
+				type branch struct {
+					Title  string
+					Leaf   leaf
+					Leaves []*leaf
+					Meta   map[string]leaf
 				}
 
 				input := struct {
-					TaggedField        string `sanitize:"strict"`
-					CompletelyUntagged completelyUntagged
+					Doc     branch `sanitize:"strict;deep"`
+					Shallow branch `sanitize:"strict"`
 				}{
-					TaggedField: "<script>alert('xss')</script>Tagged",
-					CompletelyUntagged: completelyUntagged{
-						Content: "<script>alert('xss')</script>Untagged Content",
+					Doc: branch{
+						Title:  "<b>title</b>",
+						Leaf:   leaf{Text: "<b>text</b>", Own: "<b>own</b><script>x</script>", Skip: "<b>skip</b>", Empty: "<b>empty</b>"},
+						Leaves: []*leaf{{Text: "<i>item</i>"}},
+						Meta:   map[string]leaf{"a": {Text: "<i>meta</i>"}},
 					},
+					Shallow: branch{Title: "<b>kept</b>"},
 				}
 
 				require.NoError(t, s.SanitizeStruct(&input))
-				assert.Equal(t, "Tagged", input.TaggedField)
-				assert.Equal(t, "<script>alert('xss')</script>Untagged Content", input.CompletelyUntagged.Content)
+				assert.Equal(t, "title", input.Doc.Title)
+				assert.Equal(t, leaf{Text: "text", Own: "<b>own</b>", Skip: "<b>skip</b>", Empty: "empty"}, input.Doc.Leaf)
+				assert.Equal(t, "item", input.Doc.Leaves[0].Text)
+				assert.Equal(t, "meta", input.Doc.Meta["a"].Text)
+				assert.Equal(t, "<b>kept</b>", input.Shallow.Title)
+
+				_, _, options, err := stzr.ParseTag("strict;deep")
+				require.NoError(t, err)
+				assert.Equal(t, map[string]string{"deep": "true"}, options)
+
+				invalid := struct {
+					Doc branch `sanitize:"strict;deep=yes"`
+				}{}
+				assert.ErrorIs(t, s.SanitizeStruct(&invalid), stzr.ErrInvalidTag)
+
+				// Policy names merely containing the option are not deep.
+				s.Alias("deepclean", "strict")
+				named := struct {
+					Doc branch `sanitize:"deepclean"`
+				}{Doc: branch{Title: "<b>kept</b>"}}
+				require.NoError(t, s.SanitizeStruct(&named))
+				assert.Equal(t, "<b>kept</b>", named.Doc.Title)
 			},
 		},
 		{
-			name: "generics",
+			name: "deep tag on string collections",
 			run: func(t *testing.T, s *stzr.Sanitizer) {
-				type optional[T any] struct {
-					Value T `sanitize:"strict"`
-					Set   bool
+				type entry struct {
+					Text string
+					Own  string `sanitize:"ugc"`
 				}
-
-				input := optional[string]{
-					Value: "<script>alert('xss')</script>Tagged",
-					Set:   true,
+				input := struct {
+					Lines   []string          `sanitize:"strict;deep"`
+					Pair    [2]string         `sanitize:"strict;deep"`
+					Data    map[string]string `sanitize:"strict;deep"`
+					Nested  [][]*string       `sanitize:"strict;deep"`
+					Entries []entry           `sanitize:"strict;deep"`
+					Slugs   []slug            `sanitize:"strict;deep"`
+				}{
+					Lines:   []string{"<b>a</b>", "b"},
+					Pair:    [2]string{"<i>c</i>", "<i>d</i>"},
+					Data:    map[string]string{"<b>k</b>": "<b>v</b>"},
+					Nested:  [][]*string{{new(string)}},
+					Entries: []entry{{Text: "<b>text</b>", Own: "<b>own</b>"}},
+					Slugs:   []slug{"<b>Hello World</b>"},
 				}
+				*input.Nested[0][0] = "<b>nested</b>"
 
 				require.NoError(t, s.SanitizeStruct(&input))
-				assert.Equal(t, "Tagged", input.Value)
+				assert.Equal(t, []string{"a", "b"}, input.Lines)
+				assert.Equal(t, [2]string{"c", "d"}, input.Pair)
+				assert.Equal(t, map[string]string{"<b>k</b>": "v"}, input.Data)
+				assert.Equal(t, "nested", *input.Nested[0][0])
+				assert.Equal(t, []entry{{Text: "text", Own: "<b>own</b>"}}, input.Entries)
+				assert.Equal(t, []slug{"hello-world"}, input.Slugs)
+
+				keyed := struct {
+					Data map[string]string `sanitize:"key=strict,val=strict;deep"`
+				}{Data: map[string]string{"k": "v"}}
+				assert.ErrorIs(t, s.SanitizeStruct(&keyed), stzr.ErrInvalidTag)
 			},
 		},
-	}
+		{
+			name: "named string types",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type Email string
+				optional := Email("<i>morty@citadel.com</i>")
+				input := struct {
+					Primary   Email            `sanitize:"strict"`
+					Optional  *Email           `sanitize:"strict"`
+					Secondary []Email          `sanitize:"strict"`
+					ByLabel   map[string]Email `sanitize:"strict"`
+				}{
+					Primary:   "<b>rick@citadel.com</b>",
+					Optional:  &optional,
+					Secondary: []Email{"<script>x</script>summer@citadel.com"},
+					ByLabel:   map[string]Email{"work": "<b>beth@citadel.com</b>"},
+				}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			opts := []stzr.Opt{
-				stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
-				stzr.WithPolicy("ugc", bluemonday.UGCPolicy()),
-			}
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, Email("rick@citadel.com"), input.Primary)
+				assert.Equal(t, Email("morty@citadel.com"), *input.Optional)
+				assert.Equal(t, []Email{"summer@citadel.com"}, input.Secondary)
+				assert.Equal(t, map[string]Email{"work": "beth@citadel.com"}, input.ByLabel)
+			},
+		},
+		{
+			name: "named string types keep their type",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type Slug string
+				input := struct {
+					Slug    Slug            `sanitize:"strict"`
+					Any     any             `sanitize:"strict"`
+					ByTitle map[Slug]string `sanitize:"key=strict"`
+				}{
+					Slug:    "<b>pickle-rick</b>",
+					Any:     Slug("<b>squanch</b>"),
+					ByTitle: map[Slug]string{"<i>c-137</i>": "<b>rick</b>"},
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, Slug("pickle-rick"), input.Slug)
+				assert.IsType(t, Slug(""), input.Any)
+				assert.Equal(t, Slug("squanch"), input.Any)
+				assert.Equal(t, map[Slug]string{"c-137": "<b>rick</b>"}, input.ByTitle)
+			},
+		},
+		{
+			name: "path tag",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type profile struct {
+					DisplayName string `json:"display_name,omitempty" sanitize:"strict"`
+					Bio         string `json:"-" sanitize:"unknown"`
+				}
+				type user struct {
+					Profile profile  `json:"profile"`
+					Aliases []string `sanitize:"strict"`
+				}
+
+				var paths []string
+				pathed := stzr.New(
+					stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+					stzr.WithPathTag("json"),
+					stzr.WithFieldHook(func(path, _, _, _ string) {
+						paths = append(paths, path)
+					}),
+				)
+				input := user{
+					Profile: profile{DisplayName: "<b>Rick</b>", Bio: "bio"},
+					Aliases: []string{"<i>Pickle Rick</i>"},
+				}
+
+				err := pathed.SanitizeStruct(&input)
+				var notFound *stzr.PolicyNotFoundError
+				require.ErrorAs(t, err, &notFound)
+				assert.Equal(t, "profile.Bio", notFound.Path)
+				assert.Equal(t, []string{"profile.display_name"}, paths)
+
+				pathed.Add("unknown", bluemonday.StrictPolicy())
+				paths = nil
+				require.NoError(t, pathed.SanitizeStruct(&input))
+				assert.Equal(t, []string{"profile.display_name", "profile.Bio", "Aliases[0]"}, paths)
+			},
+		},
+		{
+			name: "chained policies",
+			setup: func(s *stzr.Sanitizer) {
+				_ = s.AddPolicy("trim", stzr.PolicyFunc(strings.TrimSpace))
+			},
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				var policies []string
+				hooked := stzr.New(
+					stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+					stzr.WithPolicy("trim", stzr.PolicyFunc(strings.TrimSpace)),
+					stzr.WithFieldHook(func(_, policy, _, _ string) {
+						policies = append(policies, policy)
+					}),
+				)
+				input := struct {
+					Name string   `sanitize:"strict,trim"`
+					Tags []string `sanitize:"strict, trim"`
+				}{
+					Name: "  <b>Rick</b>  ",
+					Tags: []string{" <i>a</i> "},
+				}
+
+				require.NoError(t, hooked.SanitizeStruct(&input))
+				assert.Equal(t, "Rick", input.Name)
+				assert.Equal(t, []string{"a"}, input.Tags)
+				assert.Equal(t, []string{"strict,trim", "strict,trim"}, policies)
+
+				missing := struct {
+					Name string `sanitize:"trim,unknown"`
+				}{Name: "x"}
+				err := s.SanitizeStruct(&missing)
+				var notFound *stzr.PolicyNotFoundError
+				require.ErrorAs(t, err, &notFound)
+				assert.Equal(t, "unknown", notFound.Name)
+			},
+		},
+		{
+			name: "pointer chains",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				note := "<b>note</b>"
+				notePtr := &note
+				tags := []string{"<i>a</i>", "b"}
+				var nilPtr *string
+
+				input := struct {
+					Note    **string  `sanitize:"strict"`
+					Deep    ***string `sanitize:"strict"`
+					Tags    *[]string `sanitize:"strict"`
+					NilElem **string  `sanitize:"strict"`
+					Nil     **string  `sanitize:"strict"`
+				}{
+					Note:    &notePtr,
+					Tags:    &tags,
+					NilElem: &nilPtr,
+				}
+				deep := "<b>deep</b>"
+				deepPtr := &deep
+				deepPtrPtr := &deepPtr
+				input.Deep = &deepPtrPtr
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "note", note)
+				assert.Equal(t, "deep", deep)
+				assert.Equal(t, []string{"a", "b"}, tags)
+				assert.Nil(t, *input.NilElem)
+				assert.Nil(t, input.Nil)
+			},
+		},
+		{
+			name: "pointer elements",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type item struct {
+					Content string `sanitize:"strict"`
+				}
+				var typedNil *item
+				shared := &item{Content: "<b>shared</b>"}
+
+				input := struct {
+					Slice     []*item
+					Array     [2]*item
+					Any       []any
+					ArrayAny  [2]any
+					ByPointer map[string]*item
+				}{
+					Slice:     []*item{{Content: "<b>one</b>"}, nil, shared},
+					Array:     [2]*item{nil, {Content: "<i>two</i>"}},
+					Any:       []any{nil, typedNil, &item{Content: "<b>three</b>"}},
+					ArrayAny:  [2]any{typedNil, nil},
+					ByPointer: map[string]*item{"nil": nil, "set": {Content: "<b>four</b>"}},
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "one", input.Slice[0].Content)
+				assert.Nil(t, input.Slice[1])
+				assert.Same(t, shared, input.Slice[2])
+				assert.Equal(t, "shared", shared.Content)
+				assert.Nil(t, input.Array[0])
+				assert.Equal(t, "two", input.Array[1].Content)
+				assert.Nil(t, input.Any[0])
+				assert.Equal(t, typedNil, input.Any[1])
+				assert.Equal(t, "three", input.Any[2].(*item).Content)
+				assert.Equal(t, [2]any{typedNil, nil}, input.ArrayAny)
+				assert.Nil(t, input.ByPointer["nil"])
+				assert.Equal(t, "four", input.ByPointer["set"].Content)
+			},
+		},
+		{
+			name: "shallow",
+			options: []stzr.Opt{
+				stzr.WithShallow(),
+			},
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type item struct {
+					Content string `sanitize:"strict"`
+				}
+				input := struct {
+					Title      string `sanitize:"strict"`
+					Nested     item
+					Items      []item
+					TaggedPtr  *item    `sanitize:"strict"`
+					TaggedList []string `sanitize:"strict"`
+					Untagged   map[string]item
+				}{
+					Title:      "<b>title</b>",
+					Nested:     item{Content: "<b>nested</b>"},
+					Items:      []item{{Content: "<b>item</b>"}},
+					TaggedPtr:  &item{Content: "<b>ptr</b>"},
+					TaggedList: []string{"<b>a</b>"},
+					Untagged:   map[string]item{"k": {Content: "<b>v</b>"}},
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "title", input.Title)
+				assert.Equal(t, "<b>nested</b>", input.Nested.Content)
+				assert.Equal(t, "<b>item</b>", input.Items[0].Content)
+				assert.Equal(t, "ptr", input.TaggedPtr.Content)
+				assert.Equal(t, []string{"a"}, input.TaggedList)
+				assert.Equal(t, "<b>v</b>", input.Untagged["k"].Content)
+			},
+		},
+		{
+			name: "zero values reach policies",
+			setup: func(s *stzr.Sanitizer) {
+				_ = s.AddPolicy("fallback", stzr.PolicyFunc(func(in string) string {
+					if in == "" {
+						return "n/a"
+					}
+					return in
+				}))
+			},
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type address struct {
+					City string `sanitize:"fallback"`
+				}
+				var input struct {
+					Name    string `sanitize:"fallback"`
+					Address address
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "n/a", input.Name)
+				assert.Equal(t, "n/a", input.Address.City)
+			},
+		},
+		{
+			name:    "checked policy rejects field",
+			wantErr: true,
+			setup: func(s *stzr.Sanitizer) {
+				errURL := fmt.Errorf("urls are not allowed")
+				require.NoError(t, s.AddPolicy("nourls", stzr.PolicyErrFunc(func(in string) (string, error) {
+					if strings.Contains(in, "://") {
+						return "", errURL
+					}
+					return in, nil
+				})))
+			},
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type comment struct {
+					Body string `sanitize:"nourls"`
+				}
+				input := struct {
+					Comments []comment
+				}{
+					Comments: []comment{{Body: "fine"}, {Body: "visit https://citadel.com"}},
+				}
+
+				err := s.SanitizeStruct(&input)
+				var fieldErr *stzr.FieldError
+				require.ErrorAs(t, err, &fieldErr)
+				assert.Equal(t, "Comments[1].Body", fieldErr.Path)
+				assert.Equal(t, "nourls", fieldErr.Policy)
+				assert.EqualError(t, err, `Comments[1].Body: policy "nourls": urls are not allowed`)
+
+				_, err = s.SanitizeString("nourls", "http://x")
+				assert.EqualError(t, err, "urls are not allowed")
+				assert.Equal(t, "", stzr.PolicyErrFunc(func(string) (string, error) {
+					return "partial", err
+				}).Sanitize("x"))
+			},
+		},
+		{
+			name: "embedded unexported structs",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type base struct {
+					Name   string `sanitize:"strict"`
+					secret string `sanitize:"strict"`
+				}
+				type meta struct {
+					Note string `sanitize:"strict"`
+				}
+				type Outer struct {
+					base
+					*meta
+					Title string `sanitize:"strict"`
+				}
+
+				input := Outer{
+					base:  base{Name: "<b>Rick</b>", secret: "<b>portal</b>"},
+					meta:  &meta{Note: "<i>note</i>"},
+					Title: "<b>Title</b>",
+				}
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "Rick", input.Name)
+				assert.Equal(t, "<b>portal</b>", input.secret)
+				assert.Equal(t, "note", input.Note)
+				assert.Equal(t, "Title", input.Title)
+
+				nilMeta := Outer{Title: "<b>Title</b>"}
+				require.NoError(t, s.SanitizeStruct(&nilMeta))
+				assert.Nil(t, nilMeta.meta)
+			},
+		},
+		{
+			name: "skip on unknown policy",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type hookCall struct {
+					path, policy, before, after string
+				}
+				var calls []hookCall
+				lenient := stzr.New(
+					stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+					stzr.WithUnknownPolicy(stzr.SkipOnUnknown),
+					stzr.WithFieldHook(func(path, policy, before, after string) {
+						calls = append(calls, hookCall{path, policy, before, after})
+					}),
+				)
+				input := struct {
+					Typo  string `sanitize:"stirct"`
+					Known string `sanitize:"strict"`
+				}{
+					Typo:  "<b>typo</b>",
+					Known: "<b>known</b>",
+				}
+
+				require.NoError(t, lenient.SanitizeStruct(&input))
+				assert.Equal(t, "<b>typo</b>", input.Typo)
+				assert.Equal(t, "known", input.Known)
+				assert.Equal(t, []hookCall{
+					{"Typo", "stirct", "<b>typo</b>", "<b>typo</b>"},
+					{"Known", "strict", "<b>known</b>", "known"},
+				}, calls)
+
+				assert.ErrorIs(t, s.SanitizeStruct(&input), stzr.ErrPolicyNotFound)
+			},
+		},
+		{
+			name:    "remove policy",
+			options: []stzr.Opt{stzr.WithPolicy("removeme", bluemonday.UGCPolicy())},
+			setup: func(s *stzr.Sanitizer) {
+				result, err := s.SanitizeString("removeme", "<b>test</b>")
+				require.NoError(t, err)
+				assert.Equal(t, "<b>test</b>", result)
+
+				assert.True(t, s.Remove("removeme"))
+				assert.False(t, s.Remove("removeme"))
+			},
+			wantErr: true,
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				input := struct {
+					Field string `sanitize:"removeme"`
+				}{
+					Field: "<b>test</b>",
+				}
+
+				err := s.SanitizeStruct(&input)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "policy \"removeme\": sanitization policy not found")
+			},
+		},
+		{
+			name: "reserved policy panic with WithPolicy",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				assert.Panics(t, func() {
+					stzr.New(stzr.WithPolicy("-", bluemonday.StrictPolicy()))
+				})
+			},
+		},
+		{
+			name: "reserved policy panic with Add",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				assert.Panics(t, func() {
+					s.Add(stzr.SkipMarker, bluemonday.StrictPolicy())
+				})
+			},
+		},
+		{
+			name: "custom skip marker",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				custom := stzr.New(
+					stzr.WithSkipMarker("skip"),
+					stzr.WithPolicy("-", bluemonday.StrictPolicy()),
+					stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+				)
+				type item struct {
+					Skipped string            `sanitize:"skip"`
+					Dashed  string            `sanitize:"-"`
+					Values  map[string]string `sanitize:"key=strict"`
+				}
+				input := item{
+					Skipped: "<b>keep</b>",
+					Dashed:  "<b>clean</b>",
+					Values:  map[string]string{"<i>k</i>": "<b>v</b>"},
+				}
+
+				require.NoError(t, custom.SanitizeStruct(&input))
+				assert.Equal(t, "<b>keep</b>", input.Skipped)
+				assert.Equal(t, "clean", input.Dashed)
+				assert.Equal(t, map[string]string{"k": "<b>v</b>"}, input.Values)
+				assert.Panics(t, func() {
+					custom.Add("skip", bluemonday.StrictPolicy())
+				})
+			},
+		},
+		{
+			name: "reserved names",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				reserved := stzr.New(
+					stzr.WithReservedNames("omit", "ignore"),
+					stzr.WithPolicy("-", bluemonday.StrictPolicy()),
+				)
+				input := struct {
+					Omitted string            `sanitize:"omit"`
+					Ignored string            `sanitize:"ignore"`
+					Dashed  string            `sanitize:"-"`
+					Values  map[string]string `sanitize:"val=ignore"`
+				}{
+					Omitted: "<b>keep</b>",
+					Ignored: "<b>keep</b>",
+					Dashed:  "<b>clean</b>",
+					Values:  map[string]string{"k": "<b>keep</b>"},
+				}
+
+				require.NoError(t, reserved.SanitizeStruct(&input))
+				assert.Equal(t, "<b>keep</b>", input.Omitted)
+				assert.Equal(t, "<b>keep</b>", input.Ignored)
+				assert.Equal(t, "clean", input.Dashed)
+				assert.Equal(t, map[string]string{"k": "<b>keep</b>"}, input.Values)
+
+				assert.Panics(t, func() {
+					reserved.Alias("omit", "-")
+				})
+				assert.ErrorIs(t, reserved.AddPolicy("ignore", bluemonday.StrictPolicy()), stzr.ErrInvalidPolicyName)
+			},
+		},
+		{
+			name: "skip func",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type opaque struct {
+					Raw string `sanitize:"strict"`
+				}
+				type item struct {
+					Content string `sanitize:"strict"`
+					Opaque  opaque
+					Ptr     *opaque
+					Any     any
+					List    []opaque
+				}
+				skipping := stzr.New(
+					stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+					stzr.WithSkipFunc(func(t reflect.Type) bool {
+						return t == reflect.TypeFor[opaque]()
+					}),
+				)
+				input := item{
+					Content: "<b>clean</b>",
+					Opaque:  opaque{Raw: "<b>keep</b>"},
+					Ptr:     &opaque{Raw: "<b>keep</b>"},
+					Any:     opaque{Raw: "<b>keep</b>"},
+					List:    []opaque{{Raw: "<b>keep</b>"}},
+				}
+
+				require.NoError(t, skipping.SanitizeStruct(&input))
+				assert.Equal(t, "clean", input.Content)
+				assert.Equal(t, "<b>keep</b>", input.Opaque.Raw)
+				assert.Equal(t, "<b>keep</b>", input.Ptr.Raw)
+				assert.Equal(t, opaque{Raw: "<b>keep</b>"}, input.Any)
+				assert.Equal(t, "<b>keep</b>", input.List[0].Raw)
+			},
+		},
+		{
+			name:    "require tags",
+			options: []stzr.Opt{stzr.WithRequireTags(), stzr.WithDefaultPolicy("strict")},
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type inner struct {
+					Note string
+				}
+				tagged := struct {
+					Name    string `sanitize:"strict"`
+					Skipped string `sanitize:"-"`
+					Count   int
+					List    []string
+					private string
+				}{Name: "<b>name</b>", private: "<b>x</b>"}
+
+				require.NoError(t, s.SanitizeStruct(&tagged))
+				assert.Equal(t, "name", tagged.Name)
+
+				untagged := struct {
+					Name  string `sanitize:"strict"`
+					Inner inner
+				}{Inner: inner{Note: "<b>note</b>"}}
+
+				err := s.SanitizeStruct(&untagged)
+				assert.ErrorIs(t, err, stzr.ErrMissingTag)
+				assert.ErrorContains(t, err, "Inner.Note")
+				assert.Equal(t, "<b>note</b>", untagged.Inner.Note)
+			},
+		},
+		{
+			name: "case-insensitive policies",
+			options: []stzr.Opt{
+				stzr.WithCaseInsensitivePolicies(),
+				stzr.WithPolicy("Upper", stzr.PolicyFunc(strings.ToUpper)),
+			},
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				s.Alias("Plain", "STRICT")
+				input := struct {
+					Name  string `sanitize:"UGC"`
+					Title string `sanitize:"upper"`
+					Plain string `sanitize:"plain"`
+				}{Name: "<b>rick</b><script>x</script>", Title: "rick", Plain: "<b>plain</b>"}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "<b>rick</b>", input.Name)
+				assert.Equal(t, "RICK", input.Title)
+				assert.Equal(t, "plain", input.Plain)
+
+				got, err := s.SanitizeString("Strict", "<b>x</b>")
+				require.NoError(t, err)
+				assert.Equal(t, "x", got)
+
+				require.NoError(t, s.AddPolicy("UPPER", stzr.PolicyFunc(strings.ToLower)))
+				assert.Len(t, s.Snapshot(), 3)
+				assert.True(t, s.Remove("upper"))
+
+				// Names registered before the option fold in registration
+				// order, whatever the map order.
+				for range 20 {
+					folded := stzr.New(
+						stzr.WithPolicy("Shout", stzr.PolicyFunc(strings.ToUpper)),
+						stzr.WithPolicy("SHOUT", stzr.PolicyFunc(strings.ToLower)),
+						stzr.WithCaseInsensitivePolicies(),
+					)
+					got, err := folded.SanitizeString("shout", "Rick")
+					require.NoError(t, err)
+					assert.Equal(t, "rick", got)
+					assert.Len(t, folded.Snapshot(), 1)
+				}
+			},
+		},
+		{
+			name: "skip func matches exact types",
+			options: []stzr.Opt{
+				stzr.WithPolicy("trim", stzr.PolicyFunc(strings.TrimSpace)),
+				stzr.WithSkipFunc(func(t reflect.Type) bool {
+					return t == reflect.TypeFor[time.Time]()
+				}),
+			},
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type timestamp string
+				created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+				input := struct {
+					Created   time.Time
+					Updated   *time.Time
+					CreatedAt string    `sanitize:"trim"`
+					UpdatedAt timestamp `sanitize:"trim"`
+					History   []string  `sanitize:"trim"`
+				}{
+					Created:   created,
+					Updated:   &created,
+					CreatedAt: " 2024-01-02T03:04:05Z ",
+					UpdatedAt: " 2024-01-02T03:04:05Z ",
+					History:   []string{" 2024-01-01T00:00:00Z"},
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, created, input.Created)
+				assert.Equal(t, created, *input.Updated)
+				assert.Equal(t, "2024-01-02T03:04:05Z", input.CreatedAt)
+				assert.Equal(t, timestamp("2024-01-02T03:04:05Z"), input.UpdatedAt)
+				assert.Equal(t, []string{"2024-01-01T00:00:00Z"}, input.History)
+			},
+		},
+		{
+			name: "idempotency cache",
+			options: []stzr.Opt{
+				stzr.WithPolicy("escape", stzr.Escape),
+				stzr.WithPolicy("wrap", stzr.PolicyFunc(func(s string) string { return "[" + s + "]" })),
+				stzr.WithIdempotencyCache(),
+			},
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type item struct {
+					Title string `sanitize:"escape"`
+				}
+				shared := &item{Title: "<b>"}
+				text := "a & b"
+				input := struct {
+					First  *item
+					Second *item
+					List   []*item
+					Text   *string `sanitize:"escape"`
+					Same   *string `sanitize:"escape"`
+					Wrap   *string `sanitize:"wrap"`
+				}{First: shared, Second: shared, List: []*item{shared}, Text: &text, Same: &text, Wrap: &text}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "&lt;b&gt;", shared.Title)
+				// Other policies still visit the value.
+				assert.Equal(t, "[a &amp; b]", text)
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "&amp;lt;b&amp;gt;", shared.Title)
+			},
+		},
+		{
+			name: "reused walk state",
+			options: []stzr.Opt{
+				stzr.WithPolicy("escape", stzr.Escape),
+				stzr.WithIdempotencyCache(),
+			},
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type leaf struct {
+					Text string `sanitize:"unknown"`
+				}
+				deep := struct {
+					Groups map[string][]leaf
+				}{Groups: map[string][]leaf{"admins": {{}, {Text: "x"}}}}
+				err := s.SanitizeStruct(&deep)
+				assert.ErrorContains(t, err, "Groups[admins][0].Text")
+
+				// Paths and visited pointers of failed and finished walks do
+				// not leak into later ones.
+				shared := &struct {
+					Title string `sanitize:"escape"`
+				}{Title: "<b>"}
+				for range 2 {
+					input := struct {
+						Item *struct {
+							Title string `sanitize:"escape"`
+						}
+						Broken leaf
+					}{Item: shared}
+					err := s.SanitizeStruct(&input)
+					var notFound *stzr.PolicyNotFoundError
+					require.ErrorAs(t, err, &notFound)
+					assert.Equal(t, "Broken.Text", notFound.Path)
+				}
+				assert.Equal(t, "&amp;lt;b&amp;gt;", shared.Title)
+			},
+		},
+		{
+			name: "logger",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type inner struct {
+					Secret string `sanitize:"-"`
+					Note   string `sanitize:"unknown"`
+					hidden string `sanitize:"-"`
+					email  string `sanitize:"strict"`
+					slug   `sanitize:"strict"`
+				}
+				input := struct {
+					Name  string `sanitize:"strict"`
+					Inner inner
+				}{Name: "<b>Rick</b>", Inner: inner{email: "<b>rick@citadel.com</b>", slug: "<b>rick</b>"}}
+
+				var buf bytes.Buffer
+				logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+				logged := stzr.New(
+					stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+					stzr.WithUnknownPolicy(stzr.SkipOnUnknown),
+					stzr.WithLogger(logger),
+				)
+				require.NoError(t, logged.SanitizeStruct(&input))
+				assert.Equal(t, "Rick", input.Name)
+
+				out := buf.String()
+				assert.Contains(t, out, `msg="walking struct" path=Inner type=stzr_test.inner depth=2`)
+				assert.Contains(t, out, `msg="skipping field" path=Inner.Secret reason="reserved tag" tag=-`)
+				assert.Contains(t, out, `msg="skipping field" path=Inner.Note reason="unknown policy" policy=unknown`)
+				assert.Contains(t, out, `msg="skipping field" path=Inner.email reason="tagged but unexported" tag=strict`)
+				assert.Contains(t, out, `msg="skipping field" path=Inner.slug reason="tagged but unexported" tag=strict`)
+				assert.NotContains(t, out, "Inner.hidden")
+				assert.Equal(t, "<b>rick@citadel.com</b>", input.Inner.email)
+				assert.Equal(t, slug("<b>rick</b>"), input.Inner.slug)
+
+				buf.Reset()
+				quiet := stzr.New(
+					stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+					stzr.WithUnknownPolicy(stzr.SkipOnUnknown),
+					stzr.WithLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+				)
+				require.NoError(t, quiet.SanitizeStruct(&input))
+				assert.Empty(t, buf.String())
+			},
+		},
+		{
+			name: "empty tag uses the default policy",
+			options: []stzr.Opt{
+				stzr.WithDefaultPolicy("strict"),
+				stzr.WithRequireTags(),
+				stzr.WithShallow(),
+			},
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type inner struct {
+					Note string `sanitize:""`
+				}
+				input := struct {
+					Name    string `sanitize:""`
+					Skipped string `sanitize:"-"`
+					Inner   inner  `sanitize:""`
+					Ignored *inner
+					Tags    []string `sanitize:""`
+				}{
+					Name:    "<b>name</b>",
+					Skipped: "<b>skipped</b>",
+					Inner:   inner{Note: "<b>note</b>"},
+					Ignored: &inner{Note: "<b>ignored</b>"},
+					Tags:    []string{"<b>tag</b>"},
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "name", input.Name)
+				assert.Equal(t, "<b>skipped</b>", input.Skipped)
+				assert.Equal(t, "note", input.Inner.Note)
+				assert.Equal(t, "<b>ignored</b>", input.Ignored.Note)
+				assert.Equal(t, []string{"tag"}, input.Tags)
+
+				absent := struct{ Name string }{Name: "<b>name</b>"}
+				assert.ErrorIs(t, s.SanitizeStruct(&absent), stzr.ErrMissingTag)
+			},
+		},
+		{
+			name: "panic recovery",
+			options: []stzr.Opt{
+				stzr.WithPolicy("buggy", stzr.PolicyFunc(func(s string) string { return s[:10] })),
+				stzr.WithPanicRecovery(),
+			},
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				input := struct {
+					Name  string `sanitize:"strict"`
+					Short string `sanitize:"strict,buggy"`
+				}{Name: "<b>Rick</b>", Short: "x"}
+
+				err := s.SanitizeStruct(&input)
+				assert.ErrorIs(t, err, stzr.ErrPolicyPanic)
+				var fieldErr *stzr.FieldError
+				require.ErrorAs(t, err, &fieldErr)
+				assert.Equal(t, "Short", fieldErr.Path)
+				assert.Equal(t, "buggy", fieldErr.Policy)
+				assert.ErrorContains(t, err, "slice bounds out of range")
+				assert.Equal(t, "x", input.Short)
+
+				unprotected := stzr.New(
+					stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+					stzr.WithPolicy("buggy", stzr.PolicyFunc(func(s string) string { return s[:10] })),
+				)
+				assert.Panics(t, func() { _ = unprotected.SanitizeStruct(&input) })
+			},
+		},
+		{
+			name:    "nil pointer input",
+			wantErr: true,
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				var nilPtr *struct {
+					Field string `sanitize:"strict"`
+				}
+				err := s.SanitizeStruct(nilPtr)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "expected pointer to struct")
+			},
+		},
+		{
+			name: "zero value struct",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				input := &struct {
+					Field string `sanitize:"strict"`
+				}{}
+				err := s.SanitizeStruct(input)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "unexported fields",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				input := &struct {
+					ExportedField   string `sanitize:"strict"`
+					unexportedField string `sanitize:"strict"`
+				}{
+					ExportedField:   "<script>alert('xss')</script>Exported",
+					unexportedField: "<script>alert('xss')</script>Unexported",
+				}
+
+				err := s.SanitizeStruct(input)
+				require.NoError(t, err)
+				assert.Equal(t, "Exported", input.ExportedField)
+				assert.Equal(t, "<script>alert('xss')</script>Unexported", input.unexportedField)
+			},
+		},
+		{
+			name: "arrays",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				input := struct {
+					Items [2]struct {
+						Content string `sanitize:"strict"`
+					}
+				}{
+					Items: [2]struct {
+						Content string `sanitize:"strict"`
+					}{
+						{Content: "<script>alert('xss')</script>Item 1"},
+						{Content: "<script>alert('xss')</script>Item 2"},
+					},
+				}
+
+				err := s.SanitizeStruct(&input)
+				require.NoError(t, err)
+				assert.Equal(t, "Item 1", input.Items[0].Content)
+				assert.Equal(t, "Item 2", input.Items[1].Content)
+			},
+		},
+		{
+			name: "empty slice",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				input := struct {
+					Items []struct {
+						Content string `sanitize:"strict"`
+					}
+				}{
+					Items: []struct {
+						Content string `sanitize:"strict"`
+					}{},
+				}
+
+				err := s.SanitizeStruct(&input)
+				require.NoError(t, err)
+				assert.Empty(t, input.Items)
+			},
+		},
+		{
+			name: "nil slice",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				input := struct {
+					Items []struct {
+						Content string `sanitize:"strict"`
+					}
+				}{
+					Items: nil,
+				}
+
+				err := s.SanitizeStruct(&input)
+				require.NoError(t, err)
+				assert.Nil(t, input.Items)
+			},
+		},
+		{
+			name: "empty map",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				input := struct {
+					Data map[string]struct {
+						Content string `sanitize:"strict"`
+					}
+				}{
+					Data: make(map[string]struct {
+						Content string `sanitize:"strict"`
+					}),
+				}
+
+				err := s.SanitizeStruct(&input)
+				require.NoError(t, err)
+				assert.Empty(t, input.Data)
+			},
+		},
+		{
+			name: "nil map",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				input := struct {
+					Data map[string]struct {
+						Content string `sanitize:"strict"`
+					}
+				}{
+					Data: nil,
+				}
+
+				err := s.SanitizeStruct(&input)
+				require.NoError(t, err)
+				assert.Nil(t, input.Data)
+			},
+		},
+		{
+			name: "deeply nested pointers",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type Level3 struct {
+					Content string `sanitize:"strict"`
+				}
+				type Level2 struct {
+					Level3Ptr *Level3
+				}
+				type Level1 struct {
+					Level2Ptr *Level2
+				}
+
+				input := struct {
+					Level1Ptr *Level1
+				}{
+					Level1Ptr: &Level1{
+						Level2Ptr: &Level2{
+							Level3Ptr: &Level3{
+								Content: "<script>alert('xss')</script>Deep Content",
+							},
+						},
+					},
+				}
+
+				err := s.SanitizeStruct(&input)
+				require.NoError(t, err)
+				assert.Equal(t, "Deep Content", input.Level1Ptr.Level2Ptr.Level3Ptr.Content)
+			},
+		},
+		{
+			name: "interface with nested struct",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type NestedStruct struct {
+					Content string `sanitize:"strict"`
+				}
+
+				interfaceValue := &NestedStruct{
+					Content: "<script>alert('xss')</script>Interface Content",
+				}
+
+				input := struct {
+					Data any
+				}{
+					Data: interfaceValue,
+				}
+
+				err := s.SanitizeStruct(&input)
+				require.NoError(t, err)
+
+				nested, ok := input.Data.(*NestedStruct)
+				require.True(t, ok)
+				assert.Equal(t, "Interface Content", nested.Content)
+			},
+		},
+		{
+			name: "various reflect kinds not handled",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				input := struct {
+					IntField    int
+					FloatField  float64
+					BoolField   bool
+					StringField string `sanitize:"strict"`
+				}{
+					IntField:    42,
+					FloatField:  3.14,
+					BoolField:   true,
+					StringField: "<script>alert('xss')</script>String",
+				}
+
+				err := s.SanitizeStruct(&input)
+				require.NoError(t, err)
+
+				assert.Equal(t, 42, input.IntField)
+				assert.Equal(t, 3.14, input.FloatField)
+				assert.Equal(t, true, input.BoolField)
+				assert.Equal(t, "String", input.StringField)
+			},
+		},
+		{
+			name: "channel and function fields",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				input := struct {
+					ChannelField chan int
+					FuncField    func() string
+					StringField  string `sanitize:"strict"`
+				}{
+					ChannelField: make(chan int),
+					FuncField:    func() string { return "test" },
+					StringField:  "<script>alert('xss')</script>String",
+				}
+
+				err := s.SanitizeStruct(&input)
+				require.NoError(t, err)
+
+				assert.NotNil(t, input.ChannelField)
+				assert.NotNil(t, input.FuncField)
+				assert.Equal(t, "String", input.StringField)
+			},
+		},
+		{
+			name: "untagged fields with tagged nested",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type nestedStruct struct {
+					Content string `sanitize:"strict"`
+				}
+
+				input := struct {
+					TaggedField    string `sanitize:"strict"`
+					UntaggedField  string
+					UntaggedNested nestedStruct
+				}{
+					TaggedField:   "<script>alert('xss')</script>Tagged",
+					UntaggedField: "<script>alert('xss')</script>Untagged",
+					UntaggedNested: nestedStruct{
+						Content: "<script>alert('xss')</script>Nested Content",
+					},
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "Tagged", input.TaggedField)
+				assert.Equal(t, "<script>alert('xss')</script>Untagged", input.UntaggedField)
+				assert.Equal(t, "Nested Content", input.UntaggedNested.Content)
+			},
+		},
+		{
+			name: "untagged slice with tagged elements",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type nestedStruct struct {
+					Content string `sanitize:"strict"`
+				}
+
+				input := struct {
+					TaggedField   string `sanitize:"strict"`
+					UntaggedSlice []nestedStruct
+				}{
+					TaggedField: "<script>alert('xss')</script>Tagged",
+					UntaggedSlice: []nestedStruct{
+						{Content: "<script>alert('xss')</script>Slice Content 1"},
+						{Content: "<script>alert('xss')</script>Slice Content 2"},
+					},
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "Tagged", input.TaggedField)
+				assert.Equal(t, "Slice Content 1", input.UntaggedSlice[0].Content)
+				assert.Equal(t, "Slice Content 2", input.UntaggedSlice[1].Content)
+			},
+		},
+		{
+			name: "untagged map with tagged elements",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type nestedStruct struct {
+					Content string `sanitize:"strict"`
+				}
+
+				input := struct {
+					TaggedField string `sanitize:"strict"`
+					UntaggedMap map[string]nestedStruct
+				}{
+					TaggedField: "<script>alert('xss')</script>Tagged",
+					UntaggedMap: map[string]nestedStruct{
+						"key1": {Content: "<script>alert('xss')</script>Map Content 1"},
+						"key2": {Content: "<script>alert('xss')</script>Map Content 2"},
+					},
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "Tagged", input.TaggedField)
+				assert.Equal(t, "Map Content 1", input.UntaggedMap["key1"].Content)
+				assert.Equal(t, "Map Content 2", input.UntaggedMap["key2"].Content)
+			},
+		},
+		{
+			name: "skip tag ignores nested",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type nestedStruct struct {
+					StrictField string `sanitize:"strict"`
+					UGCField    string `sanitize:"ugc"`
+				}
+
+				input := struct {
+					TaggedField   string       `sanitize:"strict"`
+					SkippedNested nestedStruct `sanitize:"-"`
+				}{
+					TaggedField: "<script>alert('xss')</script>Tagged",
+					SkippedNested: nestedStruct{
+						StrictField: "<script>alert('xss')</script>Nested Strict",
+						UGCField:    "<script>alert('xss')</script>Nested UGC",
+					},
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "Tagged", input.TaggedField)
+				assert.Equal(t, "<script>alert('xss')</script>Nested Strict", input.SkippedNested.StrictField)
+				assert.Equal(t, "<script>alert('xss')</script>Nested UGC", input.SkippedNested.UGCField)
+			},
+		},
+		{
+			name: "completely untagged nested",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type completelyUntagged struct {
+					Content string
+				}
+
+				input := struct {
+					TaggedField        string `sanitize:"strict"`
+					CompletelyUntagged completelyUntagged
+				}{
+					TaggedField: "<script>alert('xss')</script>Tagged",
+					CompletelyUntagged: completelyUntagged{
+						Content: "<script>alert('xss')</script>Untagged Content",
+					},
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "Tagged", input.TaggedField)
+				assert.Equal(t, "<script>alert('xss')</script>Untagged Content", input.CompletelyUntagged.Content)
+			},
+		},
+		{
+			name: "generics",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type optional[T any] struct {
+					Value T `sanitize:"strict"`
+					Set   bool
+				}
+
+				input := optional[string]{
+					Value: "<script>alert('xss')</script>Tagged",
+					Set:   true,
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "Tagged", input.Value)
+			},
+		},
+		{
+			name: "generics with composite type parameters",
+			run: func(t *testing.T, s *stzr.Sanitizer) {
+				type RichText struct {
+					HTML  string `sanitize:"ugc"`
+					Plain string `sanitize:"strict"`
+				}
+				type Pair[A, B any] struct {
+					First  A `sanitize:"strict"`
+					Second B `sanitize:"strict"`
+				}
+				type optional[T any] struct {
+					Value T `sanitize:"strict"`
+					Set   bool
+				}
+
+				input := struct {
+					Mixed    Pair[string, RichText]
+					Nested   Pair[optional[RichText], *RichText]
+					Lists    Pair[[]string, map[string]RichText]
+					ZeroHalf Pair[RichText, string]
+				}{
+					Mixed: Pair[string, RichText]{
+						First:  "<b>first</b>",
+						Second: RichText{HTML: "<b>ok</b><script>x</script>", Plain: "<b>plain</b>"},
+					},
+					Nested: Pair[optional[RichText], *RichText]{
+						First:  optional[RichText]{Value: RichText{Plain: "<i>nested</i>"}, Set: true},
+						Second: &RichText{HTML: "<i>ptr</i><script>x</script>"},
+					},
+					Lists: Pair[[]string, map[string]RichText]{
+						First:  []string{"<b>a</b>"},
+						Second: map[string]RichText{"k": {Plain: "<b>v</b>"}},
+					},
+					ZeroHalf: Pair[RichText, string]{Second: "<b>second</b>"},
+				}
+
+				require.NoError(t, s.SanitizeStruct(&input))
+				assert.Equal(t, "first", input.Mixed.First)
+				assert.Equal(t, RichText{HTML: "<b>ok</b>", Plain: "plain"}, input.Mixed.Second)
+				assert.Equal(t, "nested", input.Nested.First.Value.Plain)
+				assert.Equal(t, "<i>ptr</i>", input.Nested.Second.HTML)
+				assert.Equal(t, []string{"a"}, input.Lists.First)
+				assert.Equal(t, "v", input.Lists.Second["k"].Plain)
+				assert.Equal(t, "second", input.ZeroHalf.Second)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []stzr.Opt{
+				stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+				stzr.WithPolicy("ugc", bluemonday.UGCPolicy()),
+			}
+
+			opts = append(opts, tt.options...)
+			s := stzr.New(opts...)
+			if tt.setup != nil {
+				tt.setup(s)
+			}
+			tt.run(t, s)
+		})
+	}
+}
+
+func TestSanitizer_AddPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr bool
+	}{
+		{
+			name:   "valid name",
+			policy: "upper",
+		},
+		{
+			name:    "reserved name",
+			policy:  "-",
+			wantErr: true,
+		},
+		{
+			name:    "empty name",
+			policy:  "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := stzr.New()
+			err := s.AddPolicy(tt.policy, stzr.PolicyFunc(strings.ToUpper))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, stzr.ErrInvalidPolicyName)
+				return
+			}
+			require.NoError(t, err)
+
+			result, err := s.SanitizeString(tt.policy, "rick")
+			require.NoError(t, err)
+			assert.Equal(t, "RICK", result)
+		})
+	}
+}
+
+func TestSanitizer_PolicyFactory(t *testing.T) {
+	var built atomic.Int32
+	s := stzr.New(
+		stzr.WithPolicyFactory("strict", func() stzr.Policy {
+			built.Add(1)
+			return bluemonday.StrictPolicy()
+		}),
+		stzr.WithPolicyFactory("unused", func() stzr.Policy {
+			t.Fatal("unused policy built")
+			return nil
+		}),
+	)
+	assert.Zero(t, built.Load())
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := s.SanitizeString("strict", "<b>Rick</b>")
+			assert.NoError(t, err)
+			assert.Equal(t, "Rick", got)
+		}()
+	}
+	wg.Wait()
+
+	input := struct {
+		Name string `sanitize:"strict"`
+	}{Name: "<i>Morty</i>"}
+	require.NoError(t, s.SanitizeStruct(&input))
+	assert.Equal(t, "Morty", input.Name)
+	assert.Equal(t, int32(1), built.Load())
+}
+
+func TestSanitizer_Introspection(t *testing.T) {
+	s := stzr.New(
+		stzr.WithTagKey("clean"),
+		stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+	)
+	s.Alias("plain", "strict")
+	assert.Equal(t, "clean", s.TagKey())
+	assert.Equal(t, 1, s.Len())
+
+	s.Add("ugc", bluemonday.UGCPolicy())
+	assert.Equal(t, 2, s.Len())
+
+	assert.Equal(t, "sanitize", stzr.New().TagKey())
+	assert.Zero(t, stzr.New().Len())
+}
+
+func TestSanitizer_WithScope(t *testing.T) {
+	s := stzr.New(
+		stzr.WithPolicyBuilder("ugc", bluemonday.UGCPolicy),
+		stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+		stzr.WithPolicy("tenantA:ugc", stzr.PolicyFunc(strings.ToUpper)),
+	)
+	tenantA := s.WithScope("tenantA")
+	tenantB := s.WithScope("tenantB")
+
+	type post struct {
+		Title string `sanitize:"strict"`
+		Body  string `sanitize:"ugc"`
+	}
+
+	input := post{Title: "<b>title</b>", Body: "<b>body</b>"}
+	require.NoError(t, tenantA.SanitizeStruct(&input))
+	assert.Equal(t, post{Title: "title", Body: "<B>BODY</B>"}, input)
+
+	input = post{Title: "<b>title</b>", Body: "<b>body</b>"}
+	require.NoError(t, tenantB.SanitizeStruct(&input))
+	assert.Equal(t, post{Title: "title", Body: "<b>body</b>"}, input)
+
+	got, err := tenantA.SanitizeString("ugc", "x")
+	require.NoError(t, err)
+	assert.Equal(t, "X", got)
+
+	s.Add("tenantB:strict", bluemonday.NewPolicy().AllowElements("b"))
+	got, err = tenantB.SanitizeString("strict", "<b>x</b><i>y</i>")
+	require.NoError(t, err)
+	assert.Equal(t, "<b>x</b>y", got)
+
+	derived := struct {
+		Body string `sanitize:"ugc;allow=abbr"`
+	}{Body: "<abbr>x</abbr>"}
+	require.NoError(t, tenantB.SanitizeStruct(&derived))
+	assert.Equal(t, "<abbr>x</abbr>", derived.Body)
+	assert.ErrorIs(t, tenantA.SanitizeStruct(&derived), stzr.ErrNotDerivable)
+}
+
+func TestSanitizer_Merge(t *testing.T) {
+	upper := stzr.PolicyFunc(strings.ToUpper)
+	newBase := func() *stzr.Sanitizer {
+		s := stzr.New(stzr.WithPolicy("strict", bluemonday.StrictPolicy()))
+		s.Alias("plain", "strict")
+		return s
+	}
+	module := stzr.New(
+		stzr.WithPolicyBuilder("ugc", bluemonday.UGCPolicy),
+		stzr.WithPolicy("strict", upper),
+	)
+	module.Alias("plain", "ugc")
+	module.Alias("html", "ugc")
+
+	t.Run("keep existing", func(t *testing.T) {
+		s := newBase()
+		s.Merge(module, false)
+		assert.Equal(t, 2, s.Len())
+
+		got, err := s.SanitizeString("plain", "<b>x</b>")
+		require.NoError(t, err)
+		assert.Equal(t, "x", got)
+
+		got, err = s.SanitizeString("html", "<b>x</b>")
+		require.NoError(t, err)
+		assert.Equal(t, "<b>x</b>", got)
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		s := newBase()
+		s.Merge(module, true)
+
+		got, err := s.SanitizeString("strict", "x")
+		require.NoError(t, err)
+		assert.Equal(t, "X", got)
+
+		got, err = s.SanitizeString("plain", "<b>x</b><script>y</script>")
+		require.NoError(t, err)
+		assert.Equal(t, "<b>x</b>", got)
+
+		input := struct {
+			Body string `sanitize:"ugc;allow=abbr"`
+		}{Body: "<abbr>x</abbr>"}
+		require.NoError(t, s.SanitizeStruct(&input))
+		assert.Equal(t, "<abbr>x</abbr>", input.Body)
+	})
+
+	t.Run("reserved names", func(t *testing.T) {
+		s := stzr.New(stzr.WithReservedNames("ignore"))
+		s.Merge(stzr.New(stzr.WithPolicy("ignore", upper), stzr.WithPolicy("upper", upper)), false)
+		assert.Equal(t, 1, s.Len())
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		a, b := newBase(), stzr.New(stzr.WithPolicy("upper", upper))
+		var wg sync.WaitGroup
+		for range 50 {
+			wg.Add(2)
+			go func() { defer wg.Done(); a.Merge(b, true) }()
+			go func() { defer wg.Done(); b.Merge(a, true) }()
+		}
+		wg.Wait()
+		assert.Equal(t, 2, a.Len())
+		assert.Equal(t, 2, b.Len())
+		a.Merge(a.WithScope("x"), true)
+	})
+}
+
+func TestSanitizer_Clone(t *testing.T) {
+	s := stzr.New(
+		stzr.WithPolicyBuilder("ugc", bluemonday.UGCPolicy),
+		stzr.WithTagKey("clean"),
+	)
+	s.Alias("user", "ugc")
+
+	c := s.Clone()
+	c.Add("strict", bluemonday.StrictPolicy())
+	s.Remove("user")
+
+	_, err := s.SanitizeString("strict", "x")
+	assert.ErrorIs(t, err, stzr.ErrPolicyNotFound)
+	_, err = c.SanitizeString("user", "x")
+	assert.NoError(t, err)
+
+	input := struct {
+		Body string `clean:"ugc;allow=abbr"`
+	}{Body: "<abbr>ok</abbr><script>x</script>"}
+	require.NoError(t, c.SanitizeStruct(&input))
+	assert.Equal(t, "<abbr>ok</abbr>", input.Body)
+}
+
+func TestSanitizer_SnapshotRestore(t *testing.T) {
+	s := stzr.New(
+		stzr.WithPolicyBuilder("ugc", bluemonday.UGCPolicy),
+		stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+	)
+
+	snapshot := s.Snapshot()
+	assert.Len(t, snapshot, 2)
+
+	delete(snapshot, "strict")
+	_, err := s.SanitizeString("strict", "x")
+	require.NoError(t, err, "snapshot must be a copy")
+	snapshot = s.Snapshot()
+
+	require.NoError(t, s.AddPolicy("upper", stzr.PolicyFunc(strings.ToUpper)))
+	s.Remove("ugc")
+	s.Restore(snapshot)
+
+	_, err = s.SanitizeString("upper", "x")
+	assert.ErrorIs(t, err, stzr.ErrPolicyNotFound)
+
+	input := struct {
+		Body  string `sanitize:"ugc;allow=abbr"`
+		Title string `sanitize:"strict"`
+	}{
+		Body:  "<abbr>ok</abbr>",
+		Title: "<b>title</b>",
+	}
+	require.NoError(t, s.SanitizeStruct(&input))
+	assert.Equal(t, "<abbr>ok</abbr>", input.Body)
+	assert.Equal(t, "title", input.Title)
+
+	snapshot["upper"] = stzr.PolicyFunc(strings.ToUpper)
+	_, err = s.SanitizeString("upper", "x")
+	assert.ErrorIs(t, err, stzr.ErrPolicyNotFound, "restore must copy")
+
+	t.Run("replaced policy is not derivable", func(t *testing.T) {
+		s.Restore(map[string]stzr.Policy{"ugc": bluemonday.UGCPolicy()})
+		input := struct {
+			Body string `sanitize:"ugc;allow=abbr"`
+		}{Body: "x"}
+		assert.ErrorIs(t, s.SanitizeStruct(&input), stzr.ErrNotDerivable)
+	})
+}
+
+func TestSanitizer_Reset(t *testing.T) {
+	s := stzr.NewWithDefaults(stzr.WithDefaultPolicy("strict"))
+	s.Alias("html", "ugc")
+	snapshot := s.Snapshot()
+
+	s.Reset()
+	assert.Equal(t, 0, s.Len())
+	for _, name := range []string{"strict", "ugc", "escape", "html"} {
+		_, err := s.SanitizeString(name, "x")
+		assert.ErrorIs(t, err, stzr.ErrPolicyNotFound, name)
+	}
+	input := struct{ Name string }{Name: "<b>Rick</b>"}
+	assert.ErrorIs(t, s.SanitizeStruct(&input), stzr.ErrPolicyNotFound, "default policy is kept")
+
+	s.Restore(snapshot)
+	assert.Equal(t, 3, s.Len())
+	require.NoError(t, s.SanitizeStruct(&input))
+	assert.Equal(t, "Rick", input.Name)
+
+	_, err := s.SanitizeString("html", "x")
+	assert.ErrorIs(t, err, stzr.ErrPolicyNotFound, "aliases are not restored")
+}
+
+// character is a named interface implemented by value types.
+type character interface {
+	Name() string
+}
+
+type scientist struct {
+	Bio string `sanitize:"strict"`
+}
+
+func (s scientist) Name() string   { return s.Bio }
+func (s scientist) String() string { return s.Bio }
+
+type nickname string
+
+func (n nickname) Name() string { return string(n) }
+
+// email is a value object marshaling to and from text.
+type email struct {
+	local, domain string
+}
+
+func (e email) MarshalText() ([]byte, error) {
+	return []byte(e.local + "@" + e.domain), nil
+}
+
+func (e *email) UnmarshalText(text []byte) error {
+	local, domain, ok := strings.Cut(string(text), "@")
+	if !ok {
+		return fmt.Errorf("invalid email %q", text)
+	}
+	*e = email{local: local, domain: domain}
+	return nil
+}
+
+// label only implements encoding.TextMarshaler.
+type label struct {
+	text string
+}
+
+func (l label) MarshalText() ([]byte, error) {
+	return []byte(l.text), nil
+}
+
+// secret refuses to be marshaled to text.
+type secret struct{}
+
+func (secret) MarshalText() ([]byte, error) {
+	return nil, fmt.Errorf("secret cannot be marshaled")
+}
+
+func (*secret) UnmarshalText([]byte) error {
+	return nil
+}
+
+func TestSanitizer_TextMarshalerSupport(t *testing.T) {
+	type contact struct {
+		Email    email   `sanitize:"strict"`
+		Backup   *email  `sanitize:"strict"`
+		Others   []email `sanitize:"strict"`
+		Untagged email
+	}
+
+	s := stzr.New(
+		stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+		stzr.WithPolicy("drop", stzr.PolicyFunc(func(s string) string {
+			return strings.ReplaceAll(s, "@", "")
+		})),
+		stzr.WithTextMarshalerSupport(),
+	)
+	dirty := email{local: "<b>rick</b>", domain: "c137.com"}
+
+	backup := dirty
+	input := contact{Email: dirty, Backup: &backup, Others: []email{dirty}, Untagged: dirty}
+	require.NoError(t, s.SanitizeStruct(&input))
+	clean := email{local: "rick", domain: "c137.com"}
+	assert.Equal(t, contact{Email: clean, Backup: &clean, Others: []email{clean}, Untagged: dirty}, input)
+
+	broken := struct {
+		Email email `sanitize:"drop"`
+	}{Email: dirty}
+	assert.ErrorContains(t, s.SanitizeStruct(&broken), `Email: invalid email`)
+
+	unmarshalable := struct {
+		Nested struct {
+			Secret secret `sanitize:"strict"`
+		}
+	}{}
+	assert.EqualError(t, s.SanitizeStruct(&unmarshalable), `Nested.Secret: secret cannot be marshaled`)
+
+	// Values implementing only one half are walked as usual.
+	oneWay := struct {
+		Label label `sanitize:"strict"`
+	}{Label: label{text: "<b>rick</b>"}}
+	require.NoError(t, s.SanitizeStruct(&oneWay))
+	assert.Equal(t, label{text: "<b>rick</b>"}, oneWay.Label)
+
+	input = contact{Email: dirty}
+	require.NoError(t, stzr.New(stzr.WithPolicy("strict", bluemonday.StrictPolicy())).SanitizeStruct(&input))
+	assert.Equal(t, dirty, input.Email)
+
+	// Embedded unexported values are addressable but not settable, they
+	// used to panic when converted to interfaces.
+	embedded := struct {
+		email `sanitize:"strict"`
+	}{email: dirty}
+	assert.NotPanics(t, func() {
+		require.NoError(t, s.SanitizeStruct(&embedded))
+	})
+	assert.Equal(t, dirty, embedded.email)
+}
+
+// slug is a self-sanitizing string type used to test precedence.
+type slug string
+
+func (s *slug) Sanitize(*stzr.Sanitizer) error {
+	if strings.Contains(string(*s), "/") {
+		return fmt.Errorf("slug %q contains a slash", string(*s))
+	}
+	*s = slug(strings.ToLower(strings.ReplaceAll(string(*s), " ", "-")))
+	return nil
+}
+
+func TestSanitizer_Sanitizable(t *testing.T) {
+	type post struct {
+		Tagged   slug `sanitize:"strict"`
+		Untagged slug
+		Skipped  slug   `sanitize:"-"`
+		List     []slug `sanitize:"strict"`
+	}
+
+	tests := []struct {
+		name    string
+		input   post
+		want    post
+		wantErr string
+	}{
+		{
+			name: "tag policy then type",
+			input: post{
+				Tagged:   "<b>Pickle Rick</b>",
+				Untagged: "Wubba Lubba",
+				Skipped:  "<b>Keep Me</b>",
+				List:     []slug{"<i>Get Schwifty</i>"},
+			},
+			want: post{
+				Tagged:   "pickle-rick",
+				Untagged: "wubba-lubba",
+				Skipped:  "<b>Keep Me</b>",
+				List:     []slug{"get-schwifty"},
+			},
+		},
+		{
+			name:    "type error",
+			input:   post{List: []slug{"ok", "a/b"}},
+			wantErr: `List[1]: slug "a/b" contains a slash`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := tt.input
+			err := stzr.Default().SanitizeStruct(&input)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, input)
+		})
+	}
+}
+
+// recordingObserver records removed bytes and calls per policy.
+type recordingObserver struct {
+	removed   map[string]int
+	durations map[string]int
+}
+
+func (o *recordingObserver) FieldSanitized(policy string, removed int) {
+	o.removed[policy] += removed
+}
+
+func (o *recordingObserver) Duration(policy string, d time.Duration) {
+	o.durations[policy]++
+}
+
+func TestSanitizer_ChangeChannel(t *testing.T) {
+	type comment struct {
+		Body string `sanitize:"ugc"`
+	}
+	type post struct {
+		Title    string `sanitize:"strict"`
+		Clean    string `sanitize:"strict"`
+		Comments []comment
+	}
+	input := post{
+		Title:    "<b>Title</b>",
+		Clean:    "plain",
+		Comments: []comment{{Body: "ok"}, {Body: "<script>x</script>hi"}},
+	}
+
+	ch := make(chan stzr.Change, 10)
+	s := stzr.New(
+		stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+		stzr.WithPolicy("ugc", bluemonday.UGCPolicy()),
+		stzr.WithChangeChannel(ch),
+	)
+	_, err := s.DryRun(&input)
+	require.NoError(t, err)
+	assert.Empty(t, ch, "dry runs are not published")
+
+	require.NoError(t, s.SanitizeStruct(&input))
+	close(ch)
+	var changes []stzr.Change
+	for change := range ch {
+		changes = append(changes, change)
+	}
+	assert.Equal(t, []stzr.Change{
+		{Path: "Title", Policy: "strict", Before: "<b>Title</b>", After: "Title"},
+		{Path: "Comments[1].Body", Policy: "ugc", Before: "<script>x</script>hi", After: "hi"},
+	}, changes)
+
+	t.Run("full channel drops changes", func(t *testing.T) {
+		ch := make(chan stzr.Change, 1)
+		s := stzr.New(stzr.WithPolicy("strict", bluemonday.StrictPolicy()), stzr.WithChangeChannel(ch))
+		input := struct {
+			A, B string `sanitize:"strict"`
+		}{A: "<b>a</b>", B: "<b>b</b>"}
+		require.NoError(t, s.SanitizeStruct(&input))
+		assert.Equal(t, "b", input.B)
+		require.Len(t, ch, 1)
+		assert.Equal(t, "A", (<-ch).Path)
+	})
+
+	t.Run("nil channel", func(t *testing.T) {
+		s := stzr.New(stzr.WithPolicy("strict", bluemonday.StrictPolicy()), stzr.WithChangeChannel(nil))
+		input := struct {
+			A string `sanitize:"strict"`
+		}{A: "<b>a</b>"}
+		require.NoError(t, s.SanitizeStruct(&input))
+		assert.Equal(t, "a", input.A)
+	})
+}
+
+func TestSanitizer_Observer(t *testing.T) {
+	o := &recordingObserver{removed: map[string]int{}, durations: map[string]int{}}
+	s := stzr.New(
+		stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+		stzr.WithPolicy("escape", stzr.Escape),
+		stzr.WithPolicy("trim", stzr.PolicyFunc(strings.TrimSpace)),
+		stzr.WithObserver(o),
+	)
 
-			opts = append(opts, tt.options...)
-			s := stzr.New(opts...)
-			if tt.setup != nil {
-				tt.setup(s)
+	input := struct {
+		Title string   `sanitize:"strict,trim"`
+		Code  string   `sanitize:"escape"`
+		Tags  []string `sanitize:"strict"`
+	}{
+		Title: " <b>Rick</b> ",
+		Code:  "<b>",
+		Tags:  []string{"<i>a</i>", "b"},
+	}
+
+	require.NoError(t, s.SanitizeStruct(&input))
+	assert.Equal(t, map[string]int{"strict": 14, "trim": 2, "escape": 0}, o.removed)
+	assert.Equal(t, map[string]int{"strict": 3, "trim": 1, "escape": 1}, o.durations)
+}
+
+type tenantKey struct{}
+
+// tenantPolicy strips markup for every tenant except "trusted".
+type tenantPolicy struct{}
+
+func (tenantPolicy) Sanitize(s string) string {
+	return bluemonday.StrictPolicy().Sanitize(s)
+}
+
+func (p tenantPolicy) SanitizeContext(ctx context.Context, s string) string {
+	if ctx.Value(tenantKey{}) == "trusted" {
+		return s
+	}
+	return p.Sanitize(s)
+}
+
+func TestSanitizer_SanitizeStructContext(t *testing.T) {
+	s := stzr.New(stzr.WithPolicy("tenant", tenantPolicy{}))
+	type post struct {
+		Body  string   `sanitize:"tenant"`
+		Notes []string `sanitize:"tenant"`
+	}
+	newPost := func() post {
+		return post{Body: "<b>body</b>", Notes: []string{"<i>note</i>"}}
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want post
+	}{
+		{
+			name: "trusted tenant",
+			ctx:  context.WithValue(context.Background(), tenantKey{}, "trusted"),
+			want: newPost(),
+		},
+		{
+			name: "other tenant",
+			ctx:  context.WithValue(context.Background(), tenantKey{}, "other"),
+			want: post{Body: "body", Notes: []string{"note"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := newPost()
+			require.NoError(t, s.SanitizeStructContext(tt.ctx, &input))
+			assert.Equal(t, tt.want, input)
+		})
+	}
+
+	t.Run("without context", func(t *testing.T) {
+		input := newPost()
+		require.NoError(t, s.SanitizeStruct(&input))
+		assert.Equal(t, "body", input.Body)
+	})
+}
+
+func TestSanitizer_SanitizeStructReport(t *testing.T) {
+	type item struct {
+		Content string `sanitize:"strict"`
+	}
+
+	type input struct {
+		Title string `sanitize:"strict"`
+		Items []item
+	}
+
+	tests := []struct {
+		name        string
+		input       any
+		wantChanged bool
+		wantErr     bool
+	}{
+		{
+			name:        "changed top-level field",
+			input:       &input{Title: "<b>Title</b>"},
+			wantChanged: true,
+		},
+		{
+			name:        "changed nested field",
+			input:       &input{Title: "Title", Items: []item{{Content: "<b>Item</b>"}}},
+			wantChanged: true,
+		},
+		{
+			name:  "already clean",
+			input: &input{Title: "Title", Items: []item{{Content: "Item"}}},
+		},
+		{
+			name:  "nil input",
+			input: nil,
+		},
+		{
+			name:    "non-pointer error",
+			input:   input{Title: "<b>Title</b>"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, err := stzr.Default().SanitizeStructReport(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantChanged, changed)
+		})
+	}
+}
+
+func TestSanitizer_SanitizeStructDetailed(t *testing.T) {
+	type item struct {
+		Content string `sanitize:"strict"`
+	}
+
+	type input struct {
+		Title string `sanitize:"strict"`
+		Items []item
+	}
+
+	tests := []struct {
+		name  string
+		input any
+		want  stzr.Report
+	}{
+		{
+			name:  "emptied fields",
+			input: &input{Title: "<script>alert('x')</script>", Items: []item{{Content: "ok"}, {Content: "<script>x</script>"}}},
+			want:  stzr.Report{Changed: true, EmptiedFields: []string{"Title", "Items[1].Content"}},
+		},
+		{
+			name:  "changed but not emptied",
+			input: &input{Title: "<b>Title</b>"},
+			want:  stzr.Report{Changed: true},
+		},
+		{
+			name:  "already empty",
+			input: &input{Items: []item{{Content: ""}}},
+			want:  stzr.Report{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := stzr.Default().SanitizeStructDetailed(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, report)
+		})
+	}
+}
+
+func TestSanitizer_DryRun(t *testing.T) {
+	type comment struct {
+		Author string `sanitize:"strict"`
+		Body   string `sanitize:"ugc"`
+	}
+	type post struct {
+		Title    string `sanitize:"strict"`
+		Comments []comment
+		Meta     map[string]string `sanitize:"key=strict"`
+		Any      any               `sanitize:"strict"`
+	}
+
+	input := post{
+		Title: "<b>Title</b>",
+		Comments: []comment{
+			{Author: "Rick", Body: "<b>ok</b>"},
+			{Author: "<i>Morty</i>", Body: "<script>x</script>hi"},
+		},
+		Meta: map[string]string{"<b>k</b>": "v"},
+		Any:  "<b>any</b>",
+	}
+	original := post{
+		Title:    input.Title,
+		Comments: []comment{input.Comments[0], input.Comments[1]},
+		Meta:     map[string]string{"<b>k</b>": "v"},
+		Any:      input.Any,
+	}
+
+	var hooked bool
+	s := stzr.New(
+		stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+		stzr.WithPolicy("ugc", bluemonday.UGCPolicy()),
+		stzr.WithFieldHook(func(string, string, string, string) { hooked = true }),
+	)
+
+	changes, err := s.DryRun(&input)
+	require.NoError(t, err)
+	assert.Equal(t, []stzr.Change{
+		{Path: "Title", Policy: "strict", Before: "<b>Title</b>", After: "Title"},
+		{Path: "Comments[1].Author", Policy: "strict", Before: "<i>Morty</i>", After: "Morty"},
+		{Path: "Comments[1].Body", Policy: "ugc", Before: "<script>x</script>hi", After: "hi"},
+		{Path: "Meta[<b>k</b>]", Policy: "strict", Before: "<b>k</b>", After: "k"},
+		{Path: "Any", Policy: "strict", Before: "<b>any</b>", After: "any"},
+	}, changes)
+	assert.Equal(t, original, input)
+	assert.False(t, hooked)
+
+	t.Run("matches sanitization", func(t *testing.T) {
+		var applied []stzr.Change
+		s := stzr.New(
+			stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+			stzr.WithPolicy("ugc", bluemonday.UGCPolicy()),
+			stzr.WithFieldHook(func(path, policy, before, after string) {
+				if before != after {
+					applied = append(applied, stzr.Change{Path: path, Policy: policy, Before: before, After: after})
+				}
+			}),
+		)
+
+		require.NoError(t, s.SanitizeStruct(&input))
+		assert.Equal(t, changes, applied)
+	})
+
+	t.Run("unknown policy", func(t *testing.T) {
+		input := struct {
+			Field string `sanitize:"unknown"`
+		}{Field: "<b>x</b>"}
+
+		_, err := s.DryRun(&input)
+		assert.ErrorIs(t, err, stzr.ErrPolicyNotFound)
+	})
+}
+
+func TestSanitizeStructValue(t *testing.T) {
+	type user struct {
+		Name string `sanitize:"strict"`
+	}
+	s := stzr.New(stzr.WithPolicy("strict", bluemonday.StrictPolicy()))
+
+	input := user{Name: "<b>Rick</b>"}
+	require.NoError(t, stzr.SanitizeStructValue(s, &input))
+	assert.Equal(t, "Rick", input.Name)
+
+	input = user{Name: "<b>Morty</b>"}
+	require.NoError(t, stzr.SanitizeStructValue(nil, &input))
+	assert.Equal(t, "Morty", input.Name, "nil uses the default sanitizer")
+
+	assert.ErrorContains(t, stzr.SanitizeStructValue[user](s, nil), "expected pointer to struct")
+}
+
+func TestSanitizer_SanitizeEach(t *testing.T) {
+	type user struct {
+		Name string `sanitize:"strict"`
+		Bio  string `sanitize:"ugc"`
+	}
+	s := stzr.New(
+		stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+		stzr.WithPolicy("ugc", bluemonday.UGCPolicy()),
+	)
+
+	t.Run("pointers", func(t *testing.T) {
+		users := []*user{{Name: "<b>Rick</b>"}, nil, {Bio: "<b>Morty</b><script>x</script>"}}
+		require.NoError(t, s.SanitizeEach(users))
+		assert.Equal(t, []*user{{Name: "Rick"}, nil, {Bio: "<b>Morty</b>"}}, users)
+	})
+
+	t.Run("values", func(t *testing.T) {
+		users := []user{{Name: "<b>Rick</b>"}}
+		require.NoError(t, s.SanitizeEach(users))
+		assert.Equal(t, []user{{Name: "Rick"}}, users)
+	})
+
+	t.Run("array pointer", func(t *testing.T) {
+		users := [1]user{{Name: "<b>Rick</b>"}}
+		require.NoError(t, s.SanitizeEach(&users))
+		assert.Equal(t, [1]user{{Name: "Rick"}}, users)
+	})
+
+	t.Run("slice pointer", func(t *testing.T) {
+		users := []*user{{Name: "<b>Rick</b>"}}
+		require.NoError(t, s.SanitizeEach(&users))
+		assert.Equal(t, []*user{{Name: "Rick"}}, users)
+	})
+
+	t.Run("error path", func(t *testing.T) {
+		type broken struct {
+			Name string `sanitize:"unknown"`
+		}
+		err := s.SanitizeEach([]*broken{nil, {Name: "x"}})
+		assert.ErrorIs(t, err, stzr.ErrPolicyNotFound)
+		assert.ErrorContains(t, err, "[1].Name")
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		for _, v := range []any{nil, user{}, &user{}, []string{"x"}, &[]string{"x"}, [1]user{}} {
+			assert.ErrorContains(t, s.SanitizeEach(v), "expected slice", "%T", v)
+		}
+	})
+}
+
+func TestSanitizer_SanitizeValue(t *testing.T) {
+	type user struct {
+		Name string `sanitize:"strict"`
+	}
+	type team struct {
+		Lead    user
+		Members []user
+	}
+	s := stzr.New(stzr.WithPolicy("strict", bluemonday.StrictPolicy()))
+
+	t.Run("addressable", func(t *testing.T) {
+		input := team{Lead: user{Name: "<b>Rick</b>"}, Members: []user{{Name: "<i>Morty</i>"}}}
+		rv := reflect.ValueOf(&input).Elem()
+		require.NoError(t, s.SanitizeValue(rv.Field(0)))
+		require.NoError(t, s.SanitizeValue(rv.Field(1).Index(0)))
+		assert.Equal(t, team{Lead: user{Name: "Rick"}, Members: []user{{Name: "Morty"}}}, input)
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		input := &user{Name: "<b>Rick</b>"}
+		require.NoError(t, s.SanitizeValue(reflect.ValueOf(input)))
+		assert.Equal(t, "Rick", input.Name)
+	})
+
+	t.Run("error path", func(t *testing.T) {
+		type broken struct {
+			Name string `sanitize:"unknown"`
+		}
+		err := s.SanitizeValue(reflect.ValueOf(&broken{Name: "x"}))
+		assert.ErrorIs(t, err, stzr.ErrPolicyNotFound)
+		assert.ErrorContains(t, err, "Name")
+	})
+
+	t.Run("not addressable", func(t *testing.T) {
+		for _, rv := range []reflect.Value{
+			{},
+			reflect.ValueOf(user{}),
+			reflect.ValueOf((*user)(nil)),
+			reflect.ValueOf(&struct{ user user }{}).Elem().Field(0),
+		} {
+			assert.ErrorContains(t, s.SanitizeValue(rv), "expected addressable value")
+		}
+	})
+}
+
+func TestSanitizer_SanitizeStructWith(t *testing.T) {
+	type post struct {
+		Title string   `sanitize:"strict"`
+		Body  string   `sanitize:"ugc"`
+		Notes string   `sanitize:"ugc;allow=abbr"`
+		Tags  []string `sanitize:"ugc"`
+	}
+	newPost := func() post {
+		return post{
+			Title: "<b>Title</b>",
+			Body:  "<b>Body</b>",
+			Notes: "<abbr>N</abbr>",
+			Tags:  []string{"<b>tag</b>"},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		want      post
+		errIs     error
+	}{
+		{
+			name:      "no overrides",
+			overrides: nil,
+			want: post{
+				Title: "Title",
+				Body:  "<b>Body</b>",
+				Notes: "<abbr>N</abbr>",
+				Tags:  []string{"<b>tag</b>"},
+			},
+		},
+		{
+			name:      "ugc through strict",
+			overrides: map[string]string{"ugc": "strict"},
+			want: post{
+				Title: "Title",
+				Body:  "Body",
+				Notes: "<abbr>N</abbr>",
+				Tags:  []string{"tag"},
+			},
+		},
+		{
+			name:      "unknown target",
+			overrides: map[string]string{"ugc": "unknown"},
+			errIs:     stzr.ErrPolicyNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := newPost()
+			err := stzr.Default().SanitizeStructWith(&input, tt.overrides)
+			if tt.errIs != nil {
+				assert.ErrorIs(t, err, tt.errIs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, input)
+		})
+	}
+
+	t.Run("does not persist", func(t *testing.T) {
+		input := newPost()
+		require.NoError(t, stzr.Default().SanitizeStructWith(&input, map[string]string{"ugc": "strict"}))
+
+		input = newPost()
+		require.NoError(t, stzr.Default().SanitizeStruct(&input))
+		assert.Equal(t, "<b>Body</b>", input.Body)
+	})
+}
+
+func TestSanitizer_Parallelism(t *testing.T) {
+	type item struct {
+		Content string `sanitize:"strict"`
+		Policy  string `sanitize:"-"`
+		Dynamic string
+	}
+
+	newItems := func(n int) []item {
+		items := make([]item, n)
+		for i := range items {
+			items[i] = item{Content: fmt.Sprintf("<b>%d</b>", i), Dynamic: "x"}
+		}
+		return items
+	}
+
+	// Dynamic fields of items with a policy override fail the walk.
+	resolver := stzr.WithPolicyResolver(func(sf reflect.StructField, parent reflect.Value) (string, bool) {
+		if sf.Name != "Dynamic" || parent.FieldByName("Policy").String() == "" {
+			return "", false
+		}
+		return parent.FieldByName("Policy").String(), true
+	})
+
+	tests := []struct {
+		name        string
+		parallelism int
+		items       func() []item
+		wantErrPath string
+	}{
+		{
+			name:        "sequential",
+			parallelism: 1,
+			items:       func() []item { return newItems(1000) },
+		},
+		{
+			name:        "parallel",
+			parallelism: 8,
+			items:       func() []item { return newItems(1000) },
+		},
+		{
+			name:        "parallel below threshold",
+			parallelism: 8,
+			items:       func() []item { return newItems(10) },
+		},
+		{
+			name:        "parallel returns lowest failing index",
+			parallelism: 8,
+			items: func() []item {
+				items := newItems(1000)
+				items[700].Policy = "unknown"
+				items[900].Policy = "unknown"
+				return items
+			},
+			wantErrPath: "Items[700].Dynamic",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var hooked int
+			s := stzr.New(
+				stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+				stzr.WithParallelism(tt.parallelism),
+				stzr.WithFieldHook(func(path, policy, before, after string) {
+					mu.Lock()
+					defer mu.Unlock()
+					hooked++
+				}),
+				resolver,
+			)
+
+			input := struct{ Items []item }{Items: tt.items()}
+			changed, err := s.SanitizeStructReport(&input)
+			if tt.wantErrPath != "" {
+				var notFound *stzr.PolicyNotFoundError
+				require.ErrorAs(t, err, &notFound)
+				assert.Equal(t, tt.wantErrPath, notFound.Path)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, changed)
+			assert.Equal(t, len(input.Items), hooked)
+			for i, it := range input.Items {
+				assert.Equal(t, strconv.Itoa(i), it.Content)
 			}
-			tt.run(t, s)
 		})
 	}
 }
 
 // Note: All tests on global instance should be run here and cleanup properly.
+func TestNewWithDefaults(t *testing.T) {
+	const input = "<b>bold</b>"
+
+	tests := []struct {
+		name string
+		opts []stzr.Opt
+		want map[string]string
+	}{
+		{
+			name: "preloaded policies",
+			want: map[string]string{
+				"strict": "bold",
+				"ugc":    "<b>bold</b>",
+				"escape": "&lt;b&gt;bold&lt;/b&gt;",
+			},
+		},
+		{
+			name: "user options override",
+			opts: []stzr.Opt{
+				stzr.WithPolicy("strict", stzr.PolicyFunc(strings.ToUpper)),
+				stzr.WithPolicy("custom", stzr.Escape),
+			},
+			want: map[string]string{
+				"strict": "<B>BOLD</B>",
+				"ugc":    "<b>bold</b>",
+				"custom": "&lt;b&gt;bold&lt;/b&gt;",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := stzr.NewWithDefaults(tt.opts...)
+			for policy, want := range tt.want {
+				got, err := s.SanitizeString(policy, input)
+				require.NoError(t, err)
+				assert.Equal(t, want, got, policy)
+			}
+		})
+	}
+
+	t.Run("idempotent policies", func(t *testing.T) {
+		s := stzr.NewWithDefaults()
+		inputs := []string{
+			input,
+			"a < b && c > d",
+			`<a href="https://example.com?a=1&b=2" onclick="x()">link</a>`,
+			"&lt;script&gt;alert(1)&lt;/script&gt;",
+			"<p>Tom &amp; Jerry's \"show\"</p><script>x</script>",
+		}
+		for _, policy := range []string{"strict", "ugc"} {
+			for _, in := range inputs {
+				once, err := s.SanitizeString(policy, in)
+				require.NoError(t, err)
+				twice, err := s.SanitizeString(policy, once)
+				require.NoError(t, err)
+				assert.Equal(t, once, twice, "%s(%q)", policy, in)
+			}
+		}
+	})
+}
+
+func TestDefaultWith(t *testing.T) {
+	const link = `<a href="https://citadel.com" target="_blank">citadel</a>`
+
+	s := stzr.DefaultWith(func(ugc, strict *bluemonday.Policy) {
+		ugc.AllowAttrs("target").Matching(regexp.MustCompile(`^_blank$`)).OnElements("a")
+		ugc.AddTargetBlankToFullyQualifiedLinks(true)
+		strict.AllowElements("b")
+	})
+
+	got, err := s.SanitizeString("ugc", link)
+	require.NoError(t, err)
+	assert.Equal(t, `<a href="https://citadel.com" target="_blank" rel="nofollow noopener">citadel</a>`, got)
+
+	got, err = s.SanitizeString("strict", "<b>bold</b><i>italic</i>")
+	require.NoError(t, err)
+	assert.Equal(t, "<b>bold</b>italic", got)
+
+	derived := struct {
+		Body string `sanitize:"ugc;allow=abbr"`
+	}{Body: `<abbr>x</abbr>` + link}
+	require.NoError(t, s.SanitizeStruct(&derived))
+	assert.Equal(t, `<abbr>x</abbr><a href="https://citadel.com" target="_blank" rel="nofollow noopener">citadel</a>`, derived.Body)
+
+	got, err = stzr.SanitizeString("ugc", link)
+	require.NoError(t, err)
+	assert.Equal(t, `<a href="https://citadel.com" rel="nofollow">citadel</a>`, got)
+
+	got, err = stzr.SanitizeString("strict", "<b>bold</b>")
+	require.NoError(t, err)
+	assert.Equal(t, "bold", got)
+}
+
 func TestGlobal(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -845,6 +3836,29 @@ func TestGlobal(t *testing.T) {
 				assert.Equal(t, "Hello World", input.Field)
 			},
 		},
+		{
+			name: "concurrent default updates compose",
+			run: func(t *testing.T) {
+				var wg sync.WaitGroup
+				for i := range 20 {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						stzr.UpdateDefault(func(s *stzr.Sanitizer) *stzr.Sanitizer {
+							c := s.Clone()
+							c.Alias("alias"+strconv.Itoa(i), "strict")
+							return c
+						})
+					}()
+				}
+				wg.Wait()
+
+				for i := range 20 {
+					_, err := stzr.SanitizeString("alias"+strconv.Itoa(i), "x")
+					assert.NoError(t, err)
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -861,3 +3875,193 @@ func TestGlobal(t *testing.T) {
 		})
 	}
 }
+
+// BenchmarkMapIteration compares reflective iteration over a large map by
+// collecting its keys with MapKeys and by a MapIter reusing scratch values.
+func BenchmarkMapIteration(b *testing.B) {
+	m := make(map[string]string, 100_000)
+	for i := range 100_000 {
+		m[strconv.Itoa(i)] = "Rick"
+	}
+	rv := reflect.ValueOf(m)
+
+	b.Run("MapKeys", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, key := range rv.MapKeys() {
+				_ = rv.MapIndex(key).String()
+			}
+		}
+	})
+
+	b.Run("MapIter", func(b *testing.B) {
+		b.ReportAllocs()
+		key := reflect.New(rv.Type().Key()).Elem()
+		val := reflect.New(rv.Type().Elem()).Elem()
+		for i := 0; i < b.N; i++ {
+			iter := rv.MapRange()
+			for iter.Next() {
+				key.SetIterKey(iter)
+				val.SetIterValue(iter)
+				_ = val.String()
+			}
+		}
+	})
+}
+
+func BenchmarkSanitizer_SanitizeStruct(b *testing.B) {
+	type flat struct {
+		ID      int
+		Name    string `sanitize:"noop"`
+		Email   string `sanitize:"noop"`
+		Bio     string `sanitize:"noop"`
+		Active  bool
+		Score   float64
+		Country string
+	}
+
+	s := stzr.New(stzr.WithPolicy("noop", stzr.PolicyFunc(func(s string) string { return s })))
+	input := flat{ID: 1, Name: "Rick", Email: "rick@c137", Bio: "Genius", Active: true, Score: 1, Country: "US"}
+
+	b.Run("flat", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := s.SanitizeStruct(&input); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("zero", func(b *testing.B) {
+		b.ReportAllocs()
+		var zero flat
+		for i := 0; i < b.N; i++ {
+			if err := s.SanitizeStruct(&zero); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	mapped := struct {
+		ByID map[int]flat
+	}{ByID: make(map[int]flat)}
+	for i := range 1000 {
+		mapped.ByID[i] = input
+	}
+
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := s.SanitizeStruct(&mapped); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	type node struct {
+		Title    string `sanitize:"noop"`
+		Author   flat
+		Children []node
+	}
+	nested := node{Title: "root", Author: input}
+	for range 10 {
+		child := node{Title: "child", Author: input}
+		for range 10 {
+			child.Children = append(child.Children, node{Title: "leaf", Author: input})
+		}
+		nested.Children = append(nested.Children, child)
+	}
+
+	b.Run("nested", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := s.SanitizeStruct(&nested); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	slice := struct {
+		Items []flat
+	}{Items: make([]flat, 10_000)}
+	for i := range slice.Items {
+		slice.Items[i] = input
+	}
+
+	b.Run("large slice", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := s.SanitizeStruct(&slice); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	shared := &flat{Name: "Rick"}
+	graph := struct {
+		Refs []*flat
+	}{Refs: make([]*flat, 1000)}
+	for i := range graph.Refs {
+		graph.Refs[i] = shared
+		if i%2 == 0 {
+			graph.Refs[i] = &flat{Name: "Morty"}
+		}
+	}
+	idempotent := stzr.New(stzr.WithPolicy("noop", stzr.PolicyFunc(func(s string) string { return s })), stzr.WithIdempotencyCache())
+
+	b.Run("idempotency cache", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := idempotent.SanitizeStruct(&graph); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	keyed := struct {
+		Index map[string]string `sanitize:"key=noop,val=noop"`
+	}{Index: make(map[string]string, 100_000)}
+	for i := range 100_000 {
+		keyed.Index[strconv.Itoa(i)] = "Rick"
+	}
+
+	b.Run("map keys", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := s.SanitizeStruct(&keyed); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	large := make(map[int]flat, 100_000)
+	pointers := make(map[int]*flat, 100_000)
+	for i := range 100_000 {
+		large[i] = input
+		pointers[i] = &flat{Name: "Rick"}
+	}
+	// The policy changes every value, so that copies are stored back.
+	reverse := stzr.PolicyFunc(func(s string) string {
+		r := []byte(s)
+		slices.Reverse(r)
+		return string(r)
+	})
+	reversing := stzr.New(stzr.WithPolicy("noop", reverse))
+
+	for _, bb := range []struct {
+		name string
+		v    any
+	}{
+		{"large map/values", &large},
+		{"large map/pointers", &pointers},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := reversing.SanitizeStruct(bb.v); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+// Package stzrhttp provides HTTP middleware that sanitizes request payloads
+// using a [stzr.Sanitizer].
+//
+// It lives in a separate package so that the core stzr package does not
+// depend on net/http.
+package stzrhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/kraciasty/stzr"
+)
+
+type ctxKey struct{}
+
+// ErrorHandler writes a response for an error that occurred while
+// sanitizing a request.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+type config struct {
+	errorHandler ErrorHandler
+}
+
+// Opt defines a functional option type for configuring the middleware.
+type Opt func(*config)
+
+// WithErrorHandler sets the handler used when sanitization fails.
+// By default a 500 Internal Server Error is returned.
+func WithErrorHandler(h ErrorHandler) Opt {
+	return func(c *config) {
+		c.errorHandler = h
+	}
+}
+
+// Middleware decodes the JSON request body into a value created by factory,
+// sanitizes it and stores it in the request context for the next handler.
+// The request context is passed to policies implementing [stzr.ContextPolicy].
+// The factory must return a pointer, e.g. func() any { return &Request{} }.
+//
+// Malformed bodies are rejected with 400 Bad Request, while sanitization
+// errors are passed to the configured error handler. When s is nil, the
+// default sanitizer is used.
+func Middleware(s *stzr.Sanitizer, factory func() any, opts ...Opt) func(http.Handler) http.Handler {
+	cfg := config{errorHandler: defaultErrorHandler}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			v := factory()
+			if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+
+			sanitizer := s
+			if sanitizer == nil {
+				sanitizer = stzr.Default()
+			}
+
+			if err := sanitizer.SanitizeStructContext(r.Context(), v); err != nil {
+				cfg.errorHandler(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), v)))
+		})
+	}
+}
+
+// maxMemory is the memory limit of multipart forms parsed by FormMiddleware,
+// matching the default of [http.Request.FormValue].
+const maxMemory = 32 << 20
+
+// FormMiddleware parses the request form and sanitizes every value of
+// r.Form and r.PostForm, as well as the values of multipart forms, with the
+// given policy before calling the next handler. All values of multi-value
+// fields are sanitized, while uploaded files are left untouched. Handlers
+// should read values through the request form, as r.URL.Query parses the raw
+// query again.
+//
+// Malformed forms are rejected with 400 Bad Request, while sanitization
+// errors, e.g. for an unknown policy, are passed to the configured error
+// handler. When s is nil, the default sanitizer is used.
+func FormMiddleware(s *stzr.Sanitizer, policy string, opts ...Opt) func(http.Handler) http.Handler {
+	cfg := config{errorHandler: defaultErrorHandler}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// ParseMultipartForm hides errors of ParseForm for requests that
+			// are not multipart.
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+			if err := r.ParseMultipartForm(maxMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+
+			sanitizer := s
+			if sanitizer == nil {
+				sanitizer = stzr.Default()
+			}
+
+			forms := []url.Values{r.Form, r.PostForm}
+			if r.MultipartForm != nil {
+				forms = append(forms, r.MultipartForm.Value)
+			}
+			for _, form := range forms {
+				if err := sanitizeValues(sanitizer, policy, form); err != nil {
+					cfg.errorHandler(w, r, err)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// sanitizeValues sanitizes every value of the form in place. The policy is
+// looked up even for empty forms, so that unknown policies are reported.
+func sanitizeValues(s *stzr.Sanitizer, policy string, form url.Values) error {
+	if _, err := s.SanitizeString(policy, ""); err != nil {
+		return err
+	}
+	for _, values := range form {
+		for i, v := range values {
+			clean, err := s.SanitizeString(policy, v)
+			if err != nil {
+				return err
+			}
+			values[i] = clean
+		}
+	}
+	return nil
+}
+
+// NewContext returns a copy of ctx carrying the sanitized value v.
+func NewContext(ctx context.Context, v any) context.Context {
+	return context.WithValue(ctx, ctxKey{}, v)
+}
+
+// FromContext returns the sanitized value stored by the middleware.
+func FromContext(ctx context.Context) any {
+	return ctx.Value(ctxKey{})
+}
+
+// Value returns the sanitized value stored by the middleware as T.
+// It reports false when no value of that type is present.
+func Value[T any](ctx context.Context) (T, bool) {
+	v, ok := FromContext(ctx).(T)
+	return v, ok
+}
+
+func defaultErrorHandler(w http.ResponseWriter, _ *http.Request, _ error) {
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
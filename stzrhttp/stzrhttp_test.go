@@ -0,0 +1,161 @@
+package stzrhttp_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/kraciasty/stzr"
+	"github.com/kraciasty/stzr/stzrhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type createCharacter struct {
+	Name string `json:"name" sanitize:"strict"`
+	Bio  string `json:"bio" sanitize:"ugc"`
+}
+
+type unknownPolicy struct {
+	Name string `json:"name" sanitize:"unknown"`
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		sanitizer  *stzr.Sanitizer
+		factory    func() any
+		opts       []stzrhttp.Opt
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "sanitizes decoded body",
+			factory:    func() any { return &createCharacter{} },
+			body:       `{"name":"<script>x</script>Rick <b>Sanchez</b>","bio":"<b>Genius</b><script>x</script>"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   "Rick Sanchez|<b>Genius</b>",
+		},
+		{
+			name:       "custom sanitizer",
+			sanitizer:  stzr.New(stzr.WithPolicy("strict", stzr.PolicyFunc(strings.ToUpper)), stzr.WithPolicy("ugc", stzr.PolicyFunc(strings.ToLower))),
+			factory:    func() any { return &createCharacter{} },
+			body:       `{"name":"Rick","bio":"Genius"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   "RICK|genius",
+		},
+		{
+			name:       "malformed body",
+			factory:    func() any { return &createCharacter{} },
+			body:       `{"name":`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "Bad Request\n",
+		},
+		{
+			name:       "sanitization error",
+			factory:    func() any { return &unknownPolicy{} },
+			body:       `{"name":"Rick"}`,
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "Internal Server Error\n",
+		},
+		{
+			name:    "custom error handler",
+			factory: func() any { return &unknownPolicy{} },
+			opts: []stzrhttp.Opt{
+				stzrhttp.WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+					assert.True(t, errors.Is(err, stzr.ErrPolicyNotFound))
+					http.Error(w, "unprocessable", http.StatusUnprocessableEntity)
+				}),
+			},
+			body:       `{"name":"Rick"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   "unprocessable\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				v, ok := stzrhttp.Value[*createCharacter](r.Context())
+				require.True(t, ok)
+				_, _ = w.Write([]byte(v.Name + "|" + v.Bio))
+			})
+
+			handler := stzrhttp.Middleware(tt.sanitizer, tt.factory, tt.opts...)(next)
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestFormMiddleware(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "%v|%v|%s", r.Form["name"], r.PostForm["tag"], r.URL.Query().Get("q"))
+	})
+
+	t.Run("url-encoded form", func(t *testing.T) {
+		body := url.Values{"name": {"<b>Rick</b>"}, "tag": {"<i>a</i>", "b<script>x</script>"}}.Encode()
+		req := httptest.NewRequest(http.MethodPost, "/?q=%3Cb%3Eq%3C%2Fb%3E", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		stzrhttp.FormMiddleware(nil, "strict")(echo).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "[Rick]|[a b]|<b>q</b>", rec.Body.String())
+	})
+
+	t.Run("multipart form", func(t *testing.T) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		require.NoError(t, mw.WriteField("name", "<b>Rick</b>"))
+		fw, err := mw.CreateFormFile("avatar", "rick.html")
+		require.NoError(t, err)
+		_, _ = fw.Write([]byte("<b>raw</b>"))
+		require.NoError(t, mw.Close())
+
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Rick", r.FormValue("name"))
+			assert.Equal(t, []string{"Rick"}, r.MultipartForm.Value["name"])
+			f, _, err := r.FormFile("avatar")
+			require.NoError(t, err)
+			raw, _ := io.ReadAll(f)
+			assert.Equal(t, "<b>raw</b>", string(raw))
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		rec := httptest.NewRecorder()
+		stzrhttp.FormMiddleware(nil, "strict")(next).ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("malformed form", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("%zz"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		stzrhttp.FormMiddleware(nil, "strict")(echo).ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("unknown policy", func(t *testing.T) {
+		handler := stzrhttp.FormMiddleware(nil, "unknown", stzrhttp.WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+			assert.ErrorIs(t, err, stzr.ErrPolicyNotFound)
+			http.Error(w, "unprocessable", http.StatusUnprocessableEntity)
+		}))(echo)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+}
@@ -0,0 +1,203 @@
+package stzr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tagSpec is a parsed sanitization tag, e.g. "ugc;allow=abbr,allow=cite",
+// "strict;max=1000", "strict;deep", "strict;required" or "ugc;mode=escape".
+type tagSpec struct {
+	// names are the policies applied in order.
+	names []string
+	// allow lists extra elements allowed on top of the named policy.
+	allow []string
+	// key and val are the policies applied to map keys and values.
+	key, val string
+	// max is the size limit in bytes of the sanitized value, zero when
+	// unlimited.
+	max int
+	// deep makes untagged strings in nested structs inherit the policy.
+	deep bool
+	// required rejects values emptied by sanitization.
+	required bool
+	// mode selects whether markup is stripped by the named bluemonday
+	// policies, the default, or escaped instead.
+	mode string
+}
+
+// isKeyVal reports whether the tag selects policies for map keys and values.
+func (t tagSpec) isKeyVal() bool {
+	return t.key != "" || t.val != ""
+}
+
+// hasOptions reports whether the tag tweaks the named policy.
+func (t tagSpec) hasOptions() bool {
+	return len(t.allow) > 0
+}
+
+// Values of the mode tag option.
+const (
+	modeStrip  = "strip"
+	modeEscape = "escape"
+)
+
+// escapes reports whether markup is escaped instead of stripped.
+func (t tagSpec) escapes() bool {
+	return t.mode == modeEscape
+}
+
+// policy returns the names of the policies joined as in the tag.
+func (t tagSpec) policy() string {
+	return strings.Join(t.names, ",")
+}
+
+// parseTag parses a tag of the form "name[,name...][;option[,option...]]",
+// where names are policies applied in order and options are separated by
+// commas or semicolons and have the form key=value, or are flags such as
+// deep or required. Options may also be given in place of the names, as in
+// "key=strict,val=ugc".
+func parseTag(tag string) (tagSpec, error) {
+	var spec tagSpec
+	head, rest, _ := strings.Cut(tag, ";")
+	for _, item := range strings.Split(head, ",") {
+		item = strings.TrimSpace(item)
+		if strings.Contains(item, "=") {
+			if err := spec.setOption(item); err != nil {
+				return spec, err
+			}
+			continue
+		}
+		if item != "" {
+			spec.names = append(spec.names, item)
+		}
+	}
+
+	sep := func(r rune) bool { return r == ',' || r == ';' }
+	for _, opt := range strings.FieldsFunc(rest, sep) {
+		if err := spec.setOption(strings.TrimSpace(opt)); err != nil {
+			return spec, err
+		}
+	}
+
+	if spec.isKeyVal() && (len(spec.names) > 0 || spec.hasOptions() || spec.max > 0 || spec.deep || spec.required || spec.mode != "") {
+		return spec, fmt.Errorf("key and val options in %q cannot be combined: %w", tag, ErrInvalidTag)
+	}
+	if spec.hasOptions() && spec.escapes() {
+		return spec, fmt.Errorf("allow options in %q have no effect when escaping: %w", tag, ErrInvalidTag)
+	}
+	if spec.hasOptions() && len(spec.names) > 1 {
+		return spec, fmt.Errorf("options in %q cannot be combined with chained policies: %w", tag, ErrInvalidTag)
+	}
+	if !spec.isKeyVal() && len(spec.names) == 0 {
+		return spec, fmt.Errorf("missing policy in %q: %w", tag, ErrInvalidTag)
+	}
+
+	return spec, nil
+}
+
+func (t *tagSpec) setOption(opt string) error {
+	key, value, _ := strings.Cut(opt, "=")
+	switch key {
+	case "deep", "required":
+		if value != "" {
+			return fmt.Errorf("option %q: unexpected value: %w", key, ErrInvalidTag)
+		}
+		if key == "deep" {
+			t.deep = true
+		} else {
+			t.required = true
+		}
+		return nil
+	case "allow", "key", "val":
+		if value == "" {
+			return fmt.Errorf("option %q: missing value: %w", key, ErrInvalidTag)
+		}
+	case "mode":
+		if value != modeStrip && value != modeEscape {
+			return fmt.Errorf("option %q: invalid mode %q: %w", key, value, ErrInvalidTag)
+		}
+		t.mode = value
+		return nil
+	case "max":
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("option %q: invalid size %q: %w", key, value, ErrInvalidTag)
+		}
+		t.max = n
+		return nil
+	default:
+		return fmt.Errorf("option %q: %w", key, ErrInvalidTag)
+	}
+
+	switch key {
+	case "allow":
+		t.allow = append(t.allow, value)
+	case "key":
+		t.key = value
+	case "val":
+		t.val = value
+	}
+	return nil
+}
+
+type parsedTag struct {
+	spec tagSpec
+	err  error
+}
+
+// parseTag parses the tag once and caches the result for later walks.
+func (s *Sanitizer) parseTag(tag string) (tagSpec, error) {
+	if parsed, ok := s.tags.Load(tag); ok {
+		p := parsed.(parsedTag)
+		return p.spec, p.err
+	}
+
+	spec, err := parseTag(tag)
+	s.tags.Store(tag, parsedTag{spec: spec, err: err})
+	return spec, err
+}
+
+// ParseTag parses a sanitization tag using the grammar of the walker. It
+// returns the chained policy names in the order they are applied, whether
+// the tag is the default SkipMarker, and the inline options, with repeated
+// options such as allow joined by commas. An empty tag yields no names,
+// leaving the field to the default policy. The error wraps ErrInvalidTag.
+func ParseTag(tag string) (names []string, skip bool, options map[string]string, err error) {
+	switch tag {
+	case "":
+		return nil, false, map[string]string{}, nil
+	case SkipMarker:
+		return nil, true, map[string]string{}, nil
+	}
+
+	spec, err := parseTag(tag)
+	if err != nil {
+		return nil, false, nil, err
+	}
+
+	options = make(map[string]string)
+	if spec.hasOptions() {
+		options["allow"] = strings.Join(spec.allow, ",")
+	}
+	if spec.key != "" {
+		options["key"] = spec.key
+	}
+	if spec.val != "" {
+		options["val"] = spec.val
+	}
+	if spec.max > 0 {
+		options["max"] = strconv.Itoa(spec.max)
+	}
+	if spec.deep {
+		options["deep"] = "true"
+	}
+	if spec.required {
+		options["required"] = "true"
+	}
+	if spec.mode != "" {
+		options["mode"] = spec.mode
+	}
+	return spec.names, false, options, nil
+}
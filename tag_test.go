@@ -0,0 +1,132 @@
+package stzr_test
+
+import (
+	"testing"
+
+	"github.com/kraciasty/stzr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		name        string
+		tag         string
+		wantNames   []string
+		wantSkip    bool
+		wantOptions map[string]string
+		wantErr     bool
+	}{
+		{
+			name:        "empty",
+			tag:         "",
+			wantOptions: map[string]string{},
+		},
+		{
+			name:        "skip marker",
+			tag:         stzr.SkipMarker,
+			wantSkip:    true,
+			wantOptions: map[string]string{},
+		},
+		{
+			name:        "single policy",
+			tag:         "strict",
+			wantNames:   []string{"strict"},
+			wantOptions: map[string]string{},
+		},
+		{
+			name:        "chained policies",
+			tag:         "trim, strict",
+			wantNames:   []string{"trim", "strict"},
+			wantOptions: map[string]string{},
+		},
+		{
+			name:        "inline options",
+			tag:         "ugc;allow=abbr,allow=cite",
+			wantNames:   []string{"ugc"},
+			wantOptions: map[string]string{"allow": "abbr,cite"},
+		},
+		{
+			name:        "map options",
+			tag:         "key=strict,val=ugc",
+			wantOptions: map[string]string{"key": "strict", "val": "ugc"},
+		},
+		{
+			name:        "size limit",
+			tag:         "strict,trim;max=1000",
+			wantNames:   []string{"strict", "trim"},
+			wantOptions: map[string]string{"max": "1000"},
+		},
+		{
+			name:        "required",
+			tag:         "strict;required,max=100",
+			wantNames:   []string{"strict"},
+			wantOptions: map[string]string{"required": "true", "max": "100"},
+		},
+		{
+			name:        "escape mode",
+			tag:         "ugc,trim;mode=escape",
+			wantNames:   []string{"ugc", "trim"},
+			wantOptions: map[string]string{"mode": "escape"},
+		},
+		{
+			name:    "invalid mode",
+			tag:     "ugc;mode=encode",
+			wantErr: true,
+		},
+		{
+			name:    "escape mode with allow",
+			tag:     "ugc;allow=abbr,mode=escape",
+			wantErr: true,
+		},
+		{
+			name:    "mode with key",
+			tag:     "key=strict,mode=escape",
+			wantErr: true,
+		},
+		{
+			name:    "required with value",
+			tag:     "strict;required=yes",
+			wantErr: true,
+		},
+		{
+			name:    "invalid size limit",
+			tag:     "strict;max=-1",
+			wantErr: true,
+		},
+		{
+			name:    "unknown option",
+			tag:     "ugc;deny=b",
+			wantErr: true,
+		},
+		{
+			name:    "options with chained policies",
+			tag:     "trim,ugc;allow=abbr",
+			wantErr: true,
+		},
+		{
+			name:    "key with policy",
+			tag:     "strict,key=ugc",
+			wantErr: true,
+		},
+		{
+			name:    "missing policy",
+			tag:     ";allow=abbr",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, skip, options, err := stzr.ParseTag(tt.tag)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, stzr.ErrInvalidTag)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantNames, names)
+			assert.Equal(t, tt.wantSkip, skip)
+			assert.Equal(t, tt.wantOptions, options)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package stzr
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+)
+
+// TypeHandler sanitizes a value of a type registered with RegisterType. The
+// policy is the one the value inherits from its field tag, or the default
+// policy when there is none, and may be empty.
+type TypeHandler func(rv reflect.Value, policy string) error
+
+// RegisterType makes the walker sanitize values of type t with handler
+// instead of walking them, e.g. to sanitize the string held by an optional
+// wrapper type. Handlers are consulted before any built-in handling of the
+// type and receive settable values for fields of structs passed by pointer.
+// Errors returned by the handler are prefixed with the path of the value.
+// Handlers are not called by DryRun, as they modify values in place. A nil
+// handler removes the registration. Registered types are shared with
+// scoped views and copied by Clone.
+func (s *Sanitizer) RegisterType(t reflect.Type, handler TypeHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	types := make(map[reflect.Type]TypeHandler)
+	if current := s.types.Load(); current != nil {
+		maps.Copy(types, *current)
+	}
+	if handler == nil {
+		delete(types, t)
+	} else {
+		types[t] = handler
+	}
+	s.types.Store(&types)
+}
+
+// typeHandler returns the handler registered for t, if any.
+func (s *Sanitizer) typeHandler(t reflect.Type) TypeHandler {
+	types := s.types.Load()
+	if types == nil {
+		return nil
+	}
+	return (*types)[t]
+}
+
+// sanitizeWith sanitizes rv with a registered type handler. Values the
+// handler modified are reported as changed, so copies of values held by
+// interfaces are written back.
+func (w *walker) sanitizeWith(handler TypeHandler, rv reflect.Value, policy string) error {
+	if w.dryRun || w.isReserved(policy) {
+		return nil
+	}
+	if policy == "" {
+		policy = w.defaultPolicy
+	}
+
+	var before any
+	if rv.CanInterface() {
+		before = rv.Interface()
+	}
+	if err := handler(rv, policy); err != nil {
+		if len(w.path) == 0 {
+			return err
+		}
+		return fmt.Errorf("%s: %w", w.path, err)
+	}
+	if rv.CanInterface() && !reflect.DeepEqual(before, rv.Interface()) {
+		w.changed = true
+	}
+	return nil
+}
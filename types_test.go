@@ -0,0 +1,152 @@
+package stzr_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/kraciasty/stzr"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Optional holds a value that may be absent.
+type Optional[T any] struct {
+	value T
+	ok    bool
+}
+
+func Some[T any](v T) Optional[T] { return Optional[T]{value: v, ok: true} }
+
+func (o Optional[T]) Get() (T, bool) { return o.value, o.ok }
+
+func ExampleSanitizer_RegisterType() {
+	s := stzr.NewWithDefaults()
+	s.RegisterType(reflect.TypeFor[Optional[string]](), func(rv reflect.Value, policy string) error {
+		opt := rv.Addr().Interface().(*Optional[string])
+		v, ok := opt.Get()
+		if !ok || policy == "" {
+			return nil
+		}
+		clean, err := s.SanitizeString(policy, v)
+		if err != nil {
+			return err
+		}
+		*opt = Some(clean)
+		return nil
+	})
+
+	type Profile struct {
+		Bio Optional[string] `sanitize:"strict"`
+	}
+	profile := &Profile{Bio: Some("<b>Scientist</b>")}
+	_ = s.SanitizeStruct(profile)
+
+	fmt.Println(profile.Bio.Get())
+	// Output: Scientist true
+}
+
+func TestSanitizer_RegisterType(t *testing.T) {
+	type money struct {
+		Amount   int
+		Currency string
+	}
+	type order struct {
+		Total    money   `sanitize:"strict"`
+		Discount *money  `sanitize:"-"`
+		Refunds  []money `sanitize:"ugc"`
+		Fee      money
+		Items    []any `sanitize:"strict"`
+	}
+
+	var calls []string
+	s := stzr.New(
+		stzr.WithPolicy("strict", bluemonday.StrictPolicy()),
+		stzr.WithPolicy("ugc", bluemonday.UGCPolicy()),
+	)
+	s.RegisterType(reflect.TypeFor[money](), func(rv reflect.Value, policy string) error {
+		calls = append(calls, policy)
+		if rv.CanSet() {
+			rv.FieldByName("Currency").SetString(policy)
+		}
+		return nil
+	})
+
+	input := order{
+		Total:    money{Currency: "<b>USD</b>"},
+		Discount: &money{Currency: "EUR"},
+		Refunds:  []money{{Currency: "PLN"}},
+		Items:    []any{money{}, &money{}},
+	}
+	changed, err := s.SanitizeStructReport(&input)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, []string{"strict", "ugc", "", "strict", "strict"}, calls)
+	assert.Equal(t, "strict", input.Total.Currency)
+	assert.Equal(t, "EUR", input.Discount.Currency)
+	assert.Equal(t, "ugc", input.Refunds[0].Currency)
+	assert.Equal(t, "", input.Fee.Currency)
+	assert.Equal(t, money{Currency: "strict"}, input.Items[0])
+	assert.Equal(t, &money{Currency: "strict"}, input.Items[1])
+
+	t.Run("clone and scope", func(t *testing.T) {
+		calls = nil
+		clone := s.Clone()
+		scoped := s.WithScope("tenant")
+		require.NoError(t, clone.SanitizeStruct(&struct{ M money }{}))
+		require.NoError(t, scoped.SanitizeStruct(&struct{ M money }{}))
+		assert.Len(t, calls, 2)
+	})
+
+	t.Run("default policy", func(t *testing.T) {
+		var got string
+		s := stzr.New(stzr.WithPolicy("strict", bluemonday.StrictPolicy()), stzr.WithDefaultPolicy("strict"))
+		s.RegisterType(reflect.TypeFor[money](), func(_ reflect.Value, policy string) error {
+			got = policy
+			return nil
+		})
+		require.NoError(t, s.SanitizeStruct(&struct{ M money }{}))
+		assert.Equal(t, "strict", got)
+	})
+
+	t.Run("error path", func(t *testing.T) {
+		errInvalid := errors.New("invalid money")
+		s := stzr.New()
+		s.RegisterType(reflect.TypeFor[money](), func(reflect.Value, string) error { return errInvalid })
+		err := s.SanitizeStruct(&struct{ Refunds []money }{Refunds: []money{{}}})
+		assert.ErrorIs(t, err, errInvalid)
+		assert.EqualError(t, err, "Refunds[0]: invalid money")
+	})
+
+	t.Run("replaced map pointer", func(t *testing.T) {
+		s := stzr.New()
+		s.RegisterType(reflect.TypeFor[*money](), func(rv reflect.Value, _ string) error {
+			if rv.IsNil() {
+				rv.Set(reflect.ValueOf(&money{Currency: "USD"}))
+			}
+			return nil
+		})
+		input := struct{ Prices map[string]*money }{Prices: map[string]*money{"a": nil}}
+		require.NoError(t, s.SanitizeStruct(&input))
+		assert.Equal(t, &money{Currency: "USD"}, input.Prices["a"])
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		calls = nil
+		dirty := order{Total: money{Currency: "<b>USD</b>"}}
+		changes, err := s.DryRun(&dirty)
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+		assert.Empty(t, calls)
+		assert.Equal(t, "<b>USD</b>", dirty.Total.Currency)
+	})
+
+	t.Run("unregister", func(t *testing.T) {
+		calls = nil
+		s.RegisterType(reflect.TypeFor[money](), nil)
+		require.NoError(t, s.SanitizeStruct(&input))
+		assert.Empty(t, calls)
+	})
+}